@@ -0,0 +1,159 @@
+package dorm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+)
+
+const (
+	// cellTag 从固定的单元格读取字段 例如 cell:C3 cell:Sheet1!C3 cell:定义的名称
+	// 只在Encode时读取, DecodeDocument和WriteToExcelFile按行写入时忽略cell字段, 需要通过WriteToTemplate写回模板
+	cellTag = "CELL"
+)
+
+var (
+	cellRE = regexp.MustCompile(`^[A-Za-z]{1,3}[0-9]+$`)
+)
+
+// CellReader 能按单元格地址读取值的行 用于表单类sheet中固定位置的字段
+type CellReader interface {
+	// GetCell ref为单元格地址 例如 C3 Sheet1!C3, 也可以是工作簿中定义的名称
+	GetCell(ref string) (interface{}, bool)
+}
+
+// GetCell 读取当前行所在sheet的单元格
+func (m *ExcelRow) GetCell(ref string) (interface{}, bool) {
+	if m.parser == nil {
+		return nil, false
+	}
+	sheetName, axis, ok := resolveCellRef(m.parser.file, m.metaInfo.SheetName, ref)
+	if !ok {
+		return nil, false
+	}
+	return m.parser.file.GetCellValue(sheetName, axis), true
+}
+
+// resolveCellRef 解析单元格地址, 定义的名称指向区域时使用区域左上角的单元格
+func resolveCellRef(file *excelize.File, sheetName, ref string) (string, string, bool) {
+	if data, ok := definedName(file, sheetName, ref); ok {
+		ref = data
+	}
+	if index := strings.LastIndex(ref, "!"); index >= 0 {
+		sheetName = strings.Trim(ref[:index], "'")
+		ref = ref[index+1:]
+	}
+	ref = strings.Split(strings.Replace(ref, "$", "", -1), ":")[0]
+	if !cellRE.MatchString(ref) {
+		return "", "", false
+	}
+	return sheetName, strings.ToUpper(ref), true
+}
+
+// definedName 获取工作簿中定义的名称指向的地址
+// 优先使用作用域为sheetName的名称, 其次是全局的名称, sheetName为空时使用第一个同名的名称
+func definedName(file *excelize.File, sheetName, name string) (string, bool) {
+	if file.WorkBook == nil || file.WorkBook.DefinedNames == nil {
+		return "", false
+	}
+	local := -1
+	for i, sheet := range file.WorkBook.Sheets.Sheet {
+		if sheet.Name == sheetName {
+			local = i
+			break
+		}
+	}
+	data, found := "", false
+	for _, definedName := range file.WorkBook.DefinedNames.DefinedName {
+		if definedName.Name != name {
+			continue
+		}
+		switch {
+		case definedName.LocalSheetID == nil:
+			data, found = definedName.Data, true
+		case *definedName.LocalSheetID == local:
+			return definedName.Data, true
+		case sheetName == "" && !found:
+			data, found = definedName.Data, true
+		}
+	}
+	return data, found
+}
+
+// encodeCell 从固定的单元格读取字段的值, 空单元格不设置
+func encodeCell(row *Row, ref string, field *Field) error {
+	reader := row.root().cells
+	if reader == nil {
+		return nil
+	}
+	val, ok := reader.GetCell(ref)
+	if !ok {
+		return invalidTagError(field.Name, "cell:"+ref)
+	}
+	if val == nil || val == "" {
+		return nil
+	}
+	val = ConvertToType(field.Field, val)
+	if err := field.Set(val); err != nil {
+		return withColumn(err, ref, val)
+	}
+	return nil
+}
+
+// DecodeCells 获取对象中设置了cell标签的字段, 返回单元格地址到值的映射
+// 和Encode一致, 设置了name的嵌套结构体中的cell字段也会被获取
+func DecodeCells(v interface{}, opt ...interface{}) (map[string]interface{}, error) {
+	reflectValue := reflect.Indirect(reflect.ValueOf(v))
+	if reflectValue.Kind() != reflect.Struct {
+		return nil, errors.New("v must be struct or ptr of struct")
+	}
+	cells := map[string]interface{}{}
+	decodeCells(reflectValue, cells)
+	return cells, nil
+}
+
+func decodeCells(reflectValue reflect.Value, cells map[string]interface{}) {
+	for _, fieldStruct := range structFields(reflectValue.Type()) {
+		fieldValue, ok := fieldByIndex(reflectValue, fieldStruct.Index, false)
+		if !ok {
+			continue
+		}
+		tagSettings := parseTagSetting(fieldStruct.Tag)
+		if _, ok := tagSettings["-"]; ok {
+			continue
+		}
+		if ref, ok := tagSettings[cellTag]; ok {
+			cells[ref] = indirectInterface(fieldValue)
+			continue
+		}
+		fieldValue = reflect.Indirect(fieldValue)
+		if fieldValue.Kind() == reflect.Struct && len(tagNames(tagSettings)) > 0 {
+			decodeCells(fieldValue, cells)
+		}
+	}
+}
+
+// WriteToTemplate 将对象中cell字段的值写入模板的对应单元格 sheetName为地址中没有指定sheet时使用的sheet
+func WriteToTemplate(template io.Reader, writer io.Writer, sheetName string, v interface{}, opt ...interface{}) error {
+	file, err := excelize.OpenReader(template)
+	if err != nil {
+		return err
+	}
+	cells, err := DecodeCells(v, opt...)
+	if err != nil {
+		return err
+	}
+	for ref, val := range cells {
+		sheet, axis, ok := resolveCellRef(file, sheetName, ref)
+		if !ok {
+			return fmt.Errorf("invalid cell reference %q", ref)
+		}
+		file.SetCellValue(sheet, axis, val)
+	}
+	return file.Write(writer)
+}
@@ -0,0 +1,120 @@
+package dorm
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type formOrder struct {
+	Customer string `dorm:"cell:B1"`
+	Total    int    `dorm:"cell:订单!B2"`
+	Date     string `dorm:"cell:下单日期"`
+	Remark   string `dorm:"cell:备注"`
+	Sheet    string `dorm:"meta:sheet"`
+	Row      int    `dorm:"meta:row"`
+}
+
+func formWorkbook(t *testing.T) *DocumentMapper {
+	file := newWorkbook(
+		testSheet{name: "订单", rows: [][]string{
+			{"客户", "张三"},
+			{"金额", "100"},
+			{"日期", "2024-01-02", "本地备注"},
+		}},
+		testSheet{name: "其他", rows: [][]string{
+			{"其他备注"},
+		}},
+	)
+	return openWorkbook(t, withDefinedNames(t, file,
+		`<definedName name="下单日期">订单!$B$3</definedName>`,
+		`<definedName name="备注" localSheetId="1">其他!$A$1</definedName>`,
+		`<definedName name="备注" localSheetId="0">订单!$C$3</definedName>`,
+	))
+}
+
+func TestFormLayout(t *testing.T) {
+	mapper := formWorkbook(t)
+	excelParser(mapper).SetSheetName("订单")
+	var order formOrder
+	if err := mapper.Encode(&order, LayoutForm); err != nil {
+		t.Fatal(err)
+	}
+	want := formOrder{Customer: "张三", Total: 100, Date: "2024-01-02", Remark: "本地备注", Sheet: "订单", Row: 1}
+	if order != want {
+		t.Errorf("got %+v, want %+v", order, want)
+	}
+}
+
+func TestFormLayoutOneRecordPerSheet(t *testing.T) {
+	mapper := formWorkbook(t)
+	var orders []formOrder
+	if err := mapper.Encode(&orders, LayoutForm); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("got %d records, want one per sheet", len(orders))
+	}
+	if orders[1].Sheet != "其他" || orders[1].Remark != "其他备注" || orders[1].Customer != "" {
+		t.Errorf("got %+v, want the sheet-scoped name of 其他", orders[1])
+	}
+}
+
+func TestDefinedNameScope(t *testing.T) {
+	file := excelParser(formWorkbook(t)).file
+	tests := []struct {
+		sheet string
+		name  string
+		data  string
+		found bool
+	}{
+		{sheet: "订单", name: "备注", data: "订单!$C$3", found: true},
+		{sheet: "其他", name: "备注", data: "其他!$A$1", found: true},
+		{sheet: "其他", name: "下单日期", data: "订单!$B$3", found: true},
+		{sheet: "订单", name: "不存在"},
+	}
+	for _, tt := range tests {
+		data, found := definedName(file, tt.sheet, tt.name)
+		if data != tt.data || found != tt.found {
+			t.Errorf("%s %s: got (%q, %v), want (%q, %v)", tt.sheet, tt.name, data, found, tt.data, tt.found)
+		}
+	}
+}
+
+func TestWriteToTemplate(t *testing.T) {
+	template, err := newWorkbook(testSheet{name: "订单", rows: [][]string{
+		{"客户"},
+		{"金额"},
+	}}).WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := formOrder{Customer: "李四", Total: 20}
+	cells, err := DecodeCells(&order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"B1": "李四", "订单!B2": 20, "下单日期": "", "备注": ""}
+	if !reflect.DeepEqual(cells, want) {
+		t.Errorf("got cells %v, want %v", cells, want)
+	}
+	type orderCells struct {
+		Customer string `dorm:"cell:B1"`
+		Total    int    `dorm:"cell:订单!B2"`
+	}
+	var buffer bytes.Buffer
+	if err := WriteToTemplate(template, &buffer, "订单", &orderCells{Customer: "李四", Total: 20}); err != nil {
+		t.Fatal(err)
+	}
+	mapper, err := OpenReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded orderCells
+	if err := mapper.Encode(&decoded, LayoutForm); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Customer != "李四" || decoded.Total != 20 {
+		t.Errorf("got %+v after writing to the template", decoded)
+	}
+}
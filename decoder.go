@@ -1,8 +1,9 @@
 package dorm
 
 import (
+	"encoding/json"
 	"errors"
-	"go/ast"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -12,7 +13,13 @@ import (
 )
 
 const (
-	regTag = "REG"
+	regTag      = "REG"
+	extraTag    = "EXTRA"
+	sepTag      = "SEP"
+	jsonTag     = "JSON"
+	metaTag     = "META"
+	colTag      = "COL"
+	colIndexTag = "COLINDEX"
 )
 
 var (
@@ -24,6 +31,125 @@ type Row struct {
 	TagName  string                 `json:"tag_name"`
 	Data     map[string]interface{} `json:"data"`
 	MetaInfo interface{}            `json:"meta_info"`
+	header   *Header
+	// parent 嵌套结构体和分组的行所属的父行, prefix为父行中的列名前缀
+	parent *Row
+	prefix string
+	used   map[string]bool
+	// path 当前行对应的字段路径, sources 记录字段来源的单元格, 不需要时为nil
+	path    string
+	sources map[string]CellSource
+	// cells 能按单元格地址读取值时不为nil, 只在最外层的行上设置
+	cells CellReader
+}
+
+// markUsed 标记列已被字段使用, 同时标记父行中对应的列
+func (row *Row) markUsed(key string) {
+	if row.used == nil {
+		row.used = map[string]bool{}
+	}
+	row.used[key] = true
+	if row.parent != nil {
+		row.parent.markUsed(row.prefix + key)
+	}
+}
+
+// unused 获取没有被任何字段使用的列
+func (row *Row) unused() map[string]interface{} {
+	data := map[string]interface{}{}
+	for key, val := range row.Data {
+		if key != "" && !row.used[key] {
+			data[key] = val
+		}
+	}
+	return data
+}
+
+// root 获取最外层的行
+func (row *Row) root() *Row {
+	for row.parent != nil {
+		row = row.parent
+	}
+	return row
+}
+
+// positioned 获取按位置绑定的字段使用的行
+// 位置总是相对于最外层行的表头, 嵌套结构体中的字段也指向sheet中的实际列
+func (row *Row) positioned() *Row {
+	root := row.root()
+	if root == row {
+		return row
+	}
+	if root.used == nil {
+		root.used = map[string]bool{}
+	}
+	return &Row{
+		Data:     root.Data,
+		MetaInfo: root.MetaInfo,
+		header:   root.header,
+		used:     root.used,
+		path:     row.path,
+		sources:  row.sources,
+		cells:    root.cells,
+	}
+}
+
+// columnAt 获取sheet中第index列(从1开始)在行数据中的key
+// 表头由行数据生成时行数据以列号为key, 否则使用表头中该位置的列名
+func (row *Row) columnAt(index int) string {
+	if row.header == nil || row.header.derived {
+		return ColumnLetter(index)
+	}
+	index -= row.header.column
+	if index <= 0 || index > len(row.header.Columns) {
+		return ""
+	}
+	return row.header.Columns[index-1]
+}
+
+// lookup 查找name对应的列名, 列不存在时返回空字符串
+func (row *Row) lookup(name string) (string, error) {
+	if row.header == nil {
+		row.header = dataHeader(row.Data, nil)
+	}
+	return row.header.resolve(name)
+}
+
+// value 获取列的值, 列名为空表示表头中没有该列, 不能读取表头之外没有列名的单元格
+func (row *Row) value(name string) (interface{}, bool) {
+	if name == "" {
+		return nil, false
+	}
+	val, ok := row.Data[name]
+	return val, ok
+}
+
+// subHeader 获取去掉前缀之后的子表头
+func (row *Row) subHeader(prefix string) *Header {
+	if row.header == nil {
+		return nil
+	}
+	return row.header.sub(prefix)
+}
+
+// choosePrefix 在多个语言的前缀中选择当前行数据中存在的前缀
+func (row *Row) choosePrefix(prefixes []string) string {
+	for _, prefix := range prefixes {
+		for key := range row.Data {
+			if strings.HasPrefix(key, prefix) {
+				return prefix
+			}
+		}
+	}
+	return prefixes[0]
+}
+
+// itemHeader 获取分组之后的数据的表头
+func (row *Row) itemHeader(data map[string]interface{}) *Header {
+	if row.header == nil {
+		return nil
+	}
+	return dataHeader(data, row.header.normalization)
 }
 
 // Encoder 编码器
@@ -135,31 +261,71 @@ func Encode(v interface{}, row *Row, opt ...interface{}) error {
 	}
 	reflectType := typ.Elem()
 	reflectValue := reflect.ValueOf(v).Elem()
-	for i := 0; i < reflectType.NumField(); i++ {
-		if fieldStruct := reflectType.Field(i); ast.IsExported(fieldStruct.Name) {
-			kind := reflectValue.Field(i).Kind()
-			field := &Field{
-				StructField: &StructField{
-					Struct:      fieldStruct,
-					Name:        fieldStruct.Name,
-					Tag:         fieldStruct.Tag,
-					TagSettings: parseTagSetting(fieldStruct.Tag),
-				},
-				Field: reflectValue.Field(i),
-			}
+	var extras []*Field
+	for _, fieldStruct := range structFields(reflectType) {
+		if fieldValue, ok := fieldByIndex(reflectValue, fieldStruct.Index, true); ok {
+			kind := fieldValue.Kind()
+			field := newField(fieldStruct, fieldValue, opt)
 			// is ignored field
 			if _, ok := field.TagSettingsGet("-"); ok {
 				continue
 			}
+			if index, ok := field.position(); ok {
+				positioned := row.positioned()
+				if err := encodeColumn(positioned, positioned.columnAt(index), field); err != nil {
+					return err
+				}
+				continue
+			}
+			if ref, ok := field.TagSettingsGet(cellTag); ok {
+				if err := encodeCell(row, ref, field); err != nil {
+					return err
+				}
+				continue
+			}
+			if meta, ok := field.TagSettingsGet(metaTag); ok {
+				if err := encodeMeta(row, meta, field); err != nil {
+					return err
+				}
+				continue
+			}
+			// extra field is encoded after all other fields
+			if _, ok := field.TagSettingsGet(extraTag); ok {
+				extras = append(extras, field)
+				continue
+			}
+			if _, ok := field.TagSettingsGet(jsonTag); ok {
+				if name, ok := field.encodeName(); ok {
+					if err := encodeJSON(row, name, field); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if kind == reflect.Ptr && !field.isStruct() {
+				kind = field.Struct.Type.Elem().Kind()
+			}
 			switch kind {
 			case reflect.Ptr, reflect.Struct:
 				if err := encodeStructOrPtr(row, kind, field, opt...); err != nil {
 					return err
 				}
 			case reflect.Slice:
+				if sep, ok := field.separator(); ok {
+					if name, ok := field.encodeName(); ok {
+						if err := encodeSeparated(row, name, sep, field); err != nil {
+							return err
+						}
+					}
+					continue
+				}
 				if err := encodeSlice(row, field, opt...); err != nil {
 					return err
 				}
+			case reflect.Map:
+				if err := encodeMap(row, field); err != nil {
+					return err
+				}
 			default:
 				iFace := field.Field.Addr().Interface()
 				if encoder, ok := iFace.(Encoder); ok {
@@ -173,7 +339,7 @@ func Encode(v interface{}, row *Row, opt ...interface{}) error {
 						return err
 					}
 				} else {
-					if name, ok := field.TagSettingsGet("NAME"); ok {
+					if name, ok := field.encodeName(); ok {
 						if err := encodeBase(row, name, field); err != nil {
 							return err
 						}
@@ -182,6 +348,82 @@ func Encode(v interface{}, row *Row, opt ...interface{}) error {
 			}
 		}
 	}
+	for _, field := range extras {
+		if err := encodeExtra(row, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMeta 使用行的元信息填充字段 sheet: sheet名 row: 行号 raw: 原始的行数据
+// row为用户在excel中看到的行号, 即从0开始的RowIndex加1, 纵向布局时为记录所在的列号(A列为1)
+func encodeMeta(row *Row, meta string, field *Field) error {
+	sheetName, rowIndex := locate(row.MetaInfo)
+	switch strings.ToLower(meta) {
+	case "sheet":
+		return setMeta(field, sheetName)
+	case "row":
+		if rowIndex == "" {
+			return nil
+		}
+		if index, err := strconv.Atoi(rowIndex); err == nil {
+			rowIndex = strconv.Itoa(index + 1)
+		}
+		return setMeta(field, rowIndex)
+	case "raw":
+		fieldType := field.Field.Type()
+		if fieldType.Kind() != reflect.Map || fieldType.Key().Kind() != reflect.String {
+			return invalidTagError(field.Name, "meta:"+meta)
+		}
+		raw := reflect.MakeMap(fieldType)
+		for key, val := range row.root().Data {
+			if key == "" {
+				continue
+			}
+			elem, err := convertValue(fieldType.Elem(), val)
+			if err != nil {
+				return withColumn(err, key, val)
+			}
+			raw.SetMapIndex(reflect.ValueOf(key).Convert(fieldType.Key()), elem)
+		}
+		field.Field.Set(raw)
+		return nil
+	default:
+		return invalidTagError(field.Name, "meta:"+meta)
+	}
+}
+
+func setMeta(field *Field, val string) error {
+	value, err := convertValue(field.Field.Type(), val)
+	if err != nil {
+		return err
+	}
+	field.Field.Set(value)
+	return nil
+}
+
+// encodeExtra 将没有被其他字段使用的列写入map字段
+func encodeExtra(row *Row, field *Field) error {
+	fieldType := field.Field.Type()
+	if fieldType.Kind() != reflect.Map || fieldType.Key().Kind() != reflect.String {
+		return nil
+	}
+	data := row.unused()
+	if len(data) == 0 {
+		return nil
+	}
+	if field.Field.IsNil() {
+		field.Field.Set(reflect.MakeMap(fieldType))
+	}
+	for key, val := range data {
+		elem, err := convertValue(fieldType.Elem(), val)
+		if err != nil {
+			return withColumn(err, key, val)
+		}
+		field.Field.SetMapIndex(reflect.ValueOf(key).Convert(fieldType.Key()), elem)
+		row.record(mapPath(field.Name, key), key, val)
+	}
 	return nil
 }
 
@@ -194,29 +436,31 @@ func encodeStructOrPtr(row *Row, kind reflect.Kind, field *Field, opt ...interfa
 	if !isPtr {
 		fieldInterface = field.Field.Addr().Interface()
 	}
-	if name, ok := field.TagSettingsGet("NAME"); ok {
-		tagNames := strings.Split(name, "-")
-		var prefix string
-		if len(tagNames) > 0 {
-			prefix = tagNames[0] + "-"
-		} else {
-			return nil
-		}
+	if name, ok := field.encodeName(); ok {
+		prefix := row.choosePrefix(field.prefixes())
 		nData := getPrefix(row.Data, prefix)
 		subRow := &Row{
 			TagName:  name,
 			Data:     nData,
 			MetaInfo: row.MetaInfo,
+			header:   row.subHeader(prefix),
+			parent:   row,
+			prefix:   prefix,
+			path:     row.path + field.Name + ".",
+			sources:  row.sources,
 		}
 		encoder, ok := fieldInterface.(Encoder)
 		if ok {
+			for key := range nData {
+				subRow.markUsed(key)
+			}
 			err := encoder.EncodeDocument(subRow, opt...)
 			if err != nil {
-				return err
+				return withPrefix(err, prefix)
 			}
 		} else {
 			if err := Encode(fieldInterface, subRow, opt...); err != nil {
-				return err
+				return withPrefix(err, prefix)
 			}
 		}
 	}
@@ -224,15 +468,11 @@ func encodeStructOrPtr(row *Row, kind reflect.Kind, field *Field, opt ...interfa
 }
 
 func encodeSlice(row *Row, field *Field, opt ...interface{}) error {
-	name, ok := field.TagSettingsGet("NAME")
+	name, ok := field.encodeName()
 	if !ok {
 		return nil
 	}
-	tagNames := strings.Split(name, "-")
-	var prefix string
-	if len(tagNames) > 0 {
-		prefix = tagNames[0] + "-"
-	}
+	prefix := row.choosePrefix(field.prefixes())
 	nData := getPrefix(row.Data, prefix)
 	isStruct := field.Field.Type().Elem().Kind() == reflect.Struct
 	var groupDataMaps []map[string]interface{}
@@ -260,15 +500,23 @@ func encodeSlice(row *Row, field *Field, opt ...interface{}) error {
 			TagName:  name,
 			Data:     itemMap,
 			MetaInfo: row.MetaInfo,
+			header:   row.itemHeader(itemMap),
+			parent:   row,
+			prefix:   prefix,
+			path:     itemPath(row.path, field.Name, field.Field.Len()),
+			sources:  row.sources,
 		}
 		if ok {
+			for key := range itemMap {
+				subRow.markUsed(key)
+			}
 			err := encoder.EncodeDocument(row, opt...)
 			if err != nil {
 				return err
 			}
 		} else {
 			if err := Encode(itemInterface, subRow, opt...); err != nil {
-				return err
+				return withPrefix(err, prefix)
 			}
 		}
 		if isStruct {
@@ -280,34 +528,111 @@ func encodeSlice(row *Row, field *Field, opt ...interface{}) error {
 	return nil
 }
 
+// encodeMap 将多列编码为map, key为列名中被正则的key分组或前缀之后的部分
+func encodeMap(row *Row, field *Field) error {
+	name, ok := field.encodeName()
+	if !ok {
+		return nil
+	}
+	var match func(column string) (string, bool)
+	if isReg, ok := field.TagSettingsGet(regTag); ok && isReg == "true" {
+		reg, err := regexp.Compile(name)
+		if err != nil {
+			return &FieldError{
+				Code:   CodeInvalidRegexp,
+				Column: name,
+				Detail: err.Error(),
+			}
+		}
+		match = func(column string) (string, bool) {
+			return patternKey(reg, column)
+		}
+	} else {
+		prefixes := field.prefixes()
+		match = func(column string) (string, bool) {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(column, prefix) && column != prefix {
+					return strings.TrimPrefix(column, prefix), true
+				}
+			}
+			return "", false
+		}
+	}
+	fieldType := field.Field.Type()
+	for column, val := range row.Data {
+		key, ok := match(column)
+		if !ok {
+			continue
+		}
+		row.markUsed(column)
+		mapKey, err := convertValue(fieldType.Key(), key)
+		if err != nil {
+			return withColumn(err, column, key)
+		}
+		mapVal, err := convertValue(fieldType.Elem(), val)
+		if err != nil {
+			return withColumn(err, column, val)
+		}
+		if field.Field.IsNil() {
+			field.Field.Set(reflect.MakeMap(fieldType))
+		}
+		field.Field.SetMapIndex(mapKey, mapVal)
+		row.record(mapPath(field.Name, key), column, val)
+	}
+	return nil
+}
+
+// patternKey 获取列名中正则匹配的key 优先使用名为key的分组, 其次是第一个分组, 都没有时为整个列名
+func patternKey(reg *regexp.Regexp, column string) (string, bool) {
+	match := reg.FindStringSubmatch(column)
+	if match == nil {
+		return "", false
+	}
+	for index, name := range reg.SubexpNames() {
+		if name == "key" {
+			return match[index], true
+		}
+	}
+	if len(match) > 1 {
+		return match[1], true
+	}
+	return column, true
+}
+
 func encodeWithReg(row *Row, isReg string, field *Field) error {
 	if isReg != "true" {
 		return nil
 	}
-	name, ok := field.TagSettingsGet("NAME")
+	name, ok := field.encodeName()
 	if !ok {
 		return nil
 	}
 	reg, err := regexp.Compile(name)
 	if err != nil {
-		return errors.New("regexp failed")
+		return &FieldError{
+			Code:   CodeInvalidRegexp,
+			Column: name,
+			Detail: err.Error(),
+		}
 	}
 	for key, val := range row.Data {
 		if reg.MatchString(key) {
+			row.markUsed(key)
+			row.record(field.Name, key, val)
 			if _, ok := field.TagSettingsGet("FLOAT"); ok {
 				if shift, ok := field.TagSettingsGet("SHIFT"); ok {
 					if valStr, ok := val.(string); ok {
 						floatDeci, err := decimal.NewFromString(valStr)
 						if err != nil {
-							return err
+							return withColumn(err, key, val)
 						}
 						shiftVal, err := strconv.Atoi(shift)
 						if err != nil {
-							return err
+							return invalidTagError(key, "shift:"+shift)
 						}
 						shiftedVal := floatDeci.Shift(int32(shiftVal)).Ceil().IntPart()
 						if err := field.Set(shiftedVal); err != nil {
-							return err
+							return withColumn(err, key, val)
 						}
 						continue
 					}
@@ -315,18 +640,24 @@ func encodeWithReg(row *Row, isReg string, field *Field) error {
 						floatDeci := decimal.NewFromFloat(floatVal)
 						shiftVal, err := strconv.Atoi(shift)
 						if err != nil {
-							return err
+							return invalidTagError(key, "shift:"+shift)
 						}
 						shiftedVal := floatDeci.Shift(int32(shiftVal)).Ceil().IntPart()
-						err = field.Set(shiftedVal)
-						return err
+						if err := field.Set(shiftedVal); err != nil {
+							return withColumn(err, key, val)
+						}
+						return nil
+					}
+					return &FieldError{
+						Code:   CodeInvalidValue,
+						Column: key,
+						Value:  val,
 					}
-					return errors.New("value cannot convert")
 				}
 			} else {
 				val = ConvertToType(field.Field, val)
 				if err := field.Set(val); err != nil {
-					return err
+					return withColumn(err, key, val)
 				}
 			}
 		}
@@ -334,23 +665,98 @@ func encodeWithReg(row *Row, isReg string, field *Field) error {
 	return nil
 }
 
-func encodeBase(row *Row, name string, field *Field) error {
-	val, ok := row.Data[name]
+func invalidTagError(column, setting string) error {
+	return &FieldError{
+		Code:   CodeInvalidTag,
+		Column: column,
+		Detail: setting,
+	}
+}
+
+// encodeJSON 将单元格中的JSON解析到字段
+func encodeJSON(row *Row, tagName string, field *Field) error {
+	name, err := row.lookup(tagName)
+	if err != nil {
+		return err
+	}
+	val, ok := row.value(name)
+	if !ok {
+		return nil
+	}
+	row.markUsed(name)
+	text := strings.TrimSpace(fmt.Sprint(val))
+	if text == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(text), field.Field.Addr().Interface()); err != nil {
+		return &FieldError{
+			Code:   CodeInvalidJSON,
+			Column: name,
+			Value:  val,
+			Detail: err.Error(),
+		}
+	}
+	row.record(field.Name, name, val)
+	return nil
+}
+
+// encodeSeparated 将一个单元格按分隔符拆分为切片, 每个元素去掉首尾空白之后转换类型
+func encodeSeparated(row *Row, tagName, sep string, field *Field) error {
+	name, err := row.lookup(tagName)
+	if err != nil {
+		return err
+	}
+	val, ok := row.value(name)
+	if !ok {
+		return nil
+	}
+	row.markUsed(name)
+	fieldType := field.Field.Type()
+	items := reflect.MakeSlice(fieldType, 0, 0)
+	for _, part := range strings.Split(fmt.Sprint(val), sep) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		item, err := convertValue(fieldType.Elem(), part)
+		if err != nil {
+			return withColumn(err, name, part)
+		}
+		items = reflect.Append(items, item)
+	}
+	field.Field.Set(items)
+	row.record(field.Name, name, val)
+	return nil
+}
+
+func encodeBase(row *Row, tagName string, field *Field) error {
+	name, err := row.lookup(tagName)
+	if err != nil {
+		return err
+	}
+	return encodeColumn(row, name, field)
+}
+
+// encodeColumn 将行数据中name列的值编码到字段
+func encodeColumn(row *Row, name string, field *Field) error {
+	val, ok := row.value(name)
 	if ok {
+		row.markUsed(name)
+		row.record(field.Name, name, val)
 		if _, ok := field.TagSettingsGet("FLOAT"); ok {
 			if shift, ok := field.TagSettingsGet("SHIFT"); ok {
 				if valStr, ok := val.(string); ok {
 					floatDeci, err := decimal.NewFromString(valStr)
 					if err != nil {
-						return err
+						return withColumn(err, name, val)
 					}
 					shiftVal, err := strconv.Atoi(shift)
 					if err != nil {
-						return err
+						return invalidTagError(name, "shift:"+shift)
 					}
 					shiftedVal := floatDeci.Shift(int32(shiftVal)).Ceil().IntPart()
 					if err := field.Set(shiftedVal); err != nil {
-						return err
+						return withColumn(err, name, val)
 					}
 					return nil
 				}
@@ -358,29 +764,35 @@ func encodeBase(row *Row, name string, field *Field) error {
 					floatDeci := decimal.NewFromFloat(floatVal)
 					shiftVal, err := strconv.Atoi(shift)
 					if err != nil {
-						return err
+						return invalidTagError(name, "shift:"+shift)
 					}
 					shiftedVal := floatDeci.Shift(int32(shiftVal)).Ceil().IntPart()
-					err = field.Set(shiftedVal)
-					return err
+					if err := field.Set(shiftedVal); err != nil {
+						return withColumn(err, name, val)
+					}
+					return nil
+				}
+				return &FieldError{
+					Code:   CodeInvalidValue,
+					Column: name,
+					Value:  val,
 				}
-				return errors.New("value cannot convert")
 			}
 		}
 		val = ConvertToType(field.Field, val)
 		if err := field.Set(val); err != nil {
-			return err
+			return withColumn(err, name, val)
 		}
 		if shift, ok := field.TagSettingsGet("SHIFT"); ok {
 			if field.Field.Kind() == reflect.Int64 {
 				shiftVal, err := strconv.Atoi(shift)
 				if err != nil {
-					return err
+					return invalidTagError(name, "shift:"+shift)
 				}
 				shiftedVal := decimal.New(field.Field.Int(), int32(shiftVal)).Ceil().IntPart()
 				err = field.Set(shiftedVal)
 				if err != nil {
-					return err
+					return withColumn(err, name, val)
 				}
 			}
 		}
@@ -0,0 +1,282 @@
+package dorm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMissingColumnIgnoresUntitledCells(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "库存", rows: [][]string{
+		{"名称"},
+		{"苹果", "x"},
+	}})
+	var items []reportItem
+	if err := mapper.Encode(&items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Name != "苹果" || items[0].Count != 0 {
+		t.Errorf("got %+v", items)
+	}
+}
+
+type mapItem struct {
+	Name   string            `dorm:"name:编码"`
+	Names  map[string]string `dorm:"name:名称_(?P<key>[a-z]+);reg:true"`
+	Prices map[string]int    `dorm:"name:价格-"`
+}
+
+func TestEncodeMapFields(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "商品", rows: [][]string{
+		{"编码", "名称_zh", "名称_en", "价格-零售", "价格-批发"},
+		{"P1", "苹果", "apple", "5", "3"},
+	}})
+	var items []mapItem
+	if err := mapper.Encode(&items); err != nil {
+		t.Fatal(err)
+	}
+	want := mapItem{
+		Name:   "P1",
+		Names:  map[string]string{"zh": "苹果", "en": "apple"},
+		Prices: map[string]int{"零售": 5, "批发": 3},
+	}
+	if len(items) != 1 || !reflect.DeepEqual(items[0], want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+}
+
+func TestEncodeMapConvertError(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "商品", rows: [][]string{
+		{"编码", "价格-零售"},
+		{"P1", "五元"},
+	}})
+	var items []mapItem
+	mapper.Encode(&items)
+	errs := mapper.GetErrors()
+	if len(errs) != 1 {
+		t.Fatalf("got %v, want one error", errs)
+	}
+	if fieldErr := errs[0].(*RowError).Err.(*FieldError); fieldErr.Code != CodeConvertFailed || fieldErr.Column != "价格-零售" {
+		t.Errorf("got %+v", fieldErr)
+	}
+}
+
+func TestDecodeMapFields(t *testing.T) {
+	item := &mapItem{
+		Name:   "P1",
+		Names:  map[string]string{"zh": "苹果", "en": "apple"},
+		Prices: map[string]int{"零售": 5},
+	}
+	result, keys, err := DecodeDocument(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"编码", "名称_en", "名称_zh", "价格-零售"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got keys %v, want %v", keys, want)
+	}
+	if result["名称_zh"] != "苹果" || result["价格-零售"] != 5 {
+		t.Errorf("got %v", result)
+	}
+}
+
+type sepItem struct {
+	Name  string   `dorm:"name:名称"`
+	Tags  []string `dorm:"name:标签;sep:,"`
+	Sizes []int64  `dorm:"name:尺码;sep:/"`
+}
+
+func TestEncodeSeparated(t *testing.T) {
+	tests := []struct {
+		name string
+		tags string
+		size string
+		want sepItem
+		code ErrorCode
+	}{
+		{name: "split and trim", tags: "红色, 大号 ,促销", size: "36/38", want: sepItem{Name: "a", Tags: []string{"红色", "大号", "促销"}, Sizes: []int64{36, 38}}},
+		{name: "empty elements skipped", tags: ",红色,,", size: " 40 ", want: sepItem{Name: "a", Tags: []string{"红色"}, Sizes: []int64{40}}},
+		{name: "empty cell", tags: "", size: "", want: sepItem{Name: "a", Tags: []string{}, Sizes: []int64{}}},
+		{name: "element not converted", tags: "红色", size: "36/大", code: CodeConvertFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, testSheet{name: "商品", rows: [][]string{
+				{"名称", "标签", "尺码"},
+				{"a", tt.tags, tt.size},
+			}})
+			var items []sepItem
+			err := mapper.Encode(&items)
+			if tt.code != "" {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Code != tt.code || fieldErr.Column != "尺码" || fieldErr.Value != "大" {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 1 || !reflect.DeepEqual(items[0], tt.want) {
+				t.Errorf("got %#v, want %#v", items, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSeparated(t *testing.T) {
+	result, _, err := DecodeDocument(&sepItem{Name: "a", Tags: []string{"红色", "大号"}, Sizes: []int64{36, 38}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["标签"] != "红色,大号" || result["尺码"] != "36/38" {
+		t.Errorf("got %v", result)
+	}
+}
+
+type jsonSpec struct {
+	Color string `json:"color"`
+	Size  int    `json:"size"`
+}
+
+type jsonItem struct {
+	Name  string            `dorm:"name:名称"`
+	Spec  *jsonSpec         `dorm:"name:规格;json"`
+	Attrs map[string]string `dorm:"name:属性;json"`
+	Codes []int             `dorm:"name:编码;json"`
+}
+
+func TestEncodeJSON(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "商品", rows: [][]string{
+		{"名称", "规格", "属性", "编码"},
+		{"a", `{"color":"红","size":2}`, `{"产地":"山东"}`, "[1,2]"},
+		{"b", "", "", ""},
+		{"c", `{"color":`, "", ""},
+	}})
+	var items []jsonItem
+	err := mapper.Encode(&items)
+	want := []jsonItem{
+		{Name: "a", Spec: &jsonSpec{Color: "红", Size: 2}, Attrs: map[string]string{"产地": "山东"}, Codes: []int{1, 2}},
+		{Name: "b"},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+	var rowErr *RowError
+	if !errors.As(err, &rowErr) {
+		t.Fatalf("got %v, want a row error", err)
+	}
+	if sheet, row := locate(rowErr.MetaInfo); sheet != "商品" || row != "3" {
+		t.Errorf("got %s %s, want 商品 3", sheet, row)
+	}
+	if fieldErr := rowErr.Err.(*FieldError); fieldErr.Code != CodeInvalidJSON || fieldErr.Column != "规格" {
+		t.Errorf("got %+v", fieldErr)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	item := &jsonItem{Name: "a", Spec: &jsonSpec{Color: "红", Size: 2}, Codes: []int{1}}
+	result, keys, err := DecodeDocument(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"名称", "规格", "属性", "编码"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got keys %v, want %v", keys, want)
+	}
+	if result["规格"] != `{"color":"红","size":2}` || result["属性"] != "" || result["编码"] != "[1]" {
+		t.Errorf("got %v", result)
+	}
+}
+
+type metaItem struct {
+	Name  string            `dorm:"name:名称"`
+	Sheet string            `dorm:"meta:sheet"`
+	Row   int               `dorm:"meta:row"`
+	Raw   map[string]string `dorm:"meta:raw"`
+}
+
+// csvLine 非excel解析器的行, 通过RowLocator提供位置
+type csvLine struct {
+	data map[string]interface{}
+	line string
+}
+
+func (l csvLine) GetData() map[string]interface{} { return l.data }
+
+func (l csvLine) GetMetaInfo() interface{} { return l }
+
+func (l csvLine) Locate() (string, string) { return "orders.csv", l.line }
+
+type csvParser []csvLine
+
+func (p csvParser) ReadToRows(opt ...interface{}) ([]RowInterface, error) {
+	var rows []RowInterface
+	for _, line := range p {
+		rows = append(rows, line)
+	}
+	return rows, nil
+}
+
+func TestEncodeMeta(t *testing.T) {
+	excel := openSheets(t, testSheet{name: "商品", rows: [][]string{
+		{"名称", "数量"},
+		{"苹果", "3"},
+		{"香蕉", "5"},
+	}})
+	csv := &DocumentMapper{}
+	csv.SetParser(csvParser{
+		{data: map[string]interface{}{"名称": "梨", "数量": "2"}, line: "7"},
+	})
+	tests := []struct {
+		name   string
+		mapper *DocumentMapper
+		want   []metaItem
+	}{
+		{name: "excel parser", mapper: excel, want: []metaItem{
+			{Name: "苹果", Sheet: "商品", Row: 2, Raw: map[string]string{"名称": "苹果", "数量": "3"}},
+			{Name: "香蕉", Sheet: "商品", Row: 3, Raw: map[string]string{"名称": "香蕉", "数量": "5"}},
+		}},
+		{name: "custom parser", mapper: csv, want: []metaItem{
+			{Name: "梨", Sheet: "orders.csv", Row: 8, Raw: map[string]string{"名称": "梨", "数量": "2"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []metaItem
+			if err := tt.mapper.Encode(&items); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(items, tt.want) {
+				t.Errorf("got %+v, want %+v", items, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeInvalidMeta(t *testing.T) {
+	type invalidMeta struct {
+		Name string `dorm:"name:名称"`
+		Raw  string `dorm:"meta:raw"`
+	}
+	mapper := openSheets(t, testSheet{name: "商品", rows: [][]string{{"名称"}, {"苹果"}}})
+	var items []invalidMeta
+	err := mapper.Encode(&items)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Code != CodeInvalidTag || fieldErr.Detail != "meta:raw" {
+		t.Errorf("got %v, want an invalid tag error", err)
+	}
+}
+
+func TestWriteMapKeysOfAllRecords(t *testing.T) {
+	items := []*mapItem{
+		{Name: "P1", Prices: map[string]int{"零售": 5}},
+		{Name: "P2", Prices: map[string]int{"批发": 3}},
+	}
+	want := [][]string{
+		{"编码", "价格-零售", "价格-批发"},
+		{"P1", "5", ""},
+		{"P2", "", "3"},
+	}
+	if rows := writeAndRead(t, items); !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v, want %v", rows, want)
+	}
+}
@@ -0,0 +1,206 @@
+package dorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Discriminator 按鉴别列的值将同一个sheet中的行解码为不同的类型
+// 编码时作为opt传入, Register注册的类型追加到v指向的接口切片, Bind绑定的类型追加到各自的切片
+type Discriminator struct {
+	Column  string
+	types   map[string]reflect.Type
+	targets map[string]reflect.Value
+	err     error
+}
+
+// NewDiscriminator 实例化一个鉴别器 column为鉴别列的名称
+func NewDiscriminator(column string) *Discriminator {
+	return &Discriminator{
+		Column:  column,
+		types:   map[string]reflect.Type{},
+		targets: map[string]reflect.Value{},
+	}
+}
+
+// Register 注册鉴别列的值对应的类型 prototype为结构体或结构体指针, 解码出的对象追加到v指向的切片中
+func (d *Discriminator) Register(value string, prototype interface{}) *Discriminator {
+	d.types[value] = reflect.TypeOf(prototype)
+	return d
+}
+
+// Bind 将鉴别列的值对应的行解码并追加到slice指向的切片中 类型为切片的元素类型
+func (d *Discriminator) Bind(value string, slice interface{}) *Discriminator {
+	target := reflect.ValueOf(slice)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Slice {
+		d.err = fmt.Errorf("bind %q: slice must be ptr of slice", value)
+		return d
+	}
+	d.types[value] = target.Elem().Type().Elem()
+	d.targets[value] = target.Elem()
+	return d
+}
+
+// columns 鉴别列和所有注册类型映射的列的并集, 鉴别列为必填
+func (d *Discriminator) columns(strategy NamingStrategy) []columnSpec {
+	var values []string
+	for value := range d.types {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	specGroups := [][]columnSpec{{{
+		prefixes: []string{""},
+		names:    splitAliases(d.Column),
+		required: true,
+		tagName:  d.Column,
+	}}}
+	for _, value := range values {
+		specGroups = append(specGroups, typeColumns(d.types[value], []string{""}, strategy))
+	}
+	return unionSpecs(specGroups...)
+}
+
+// target 获取行对应的类型和追加的切片
+func (d *Discriminator) target(rowInterface RowInterface, defaultTarget reflect.Value, opt []interface{}) (reflect.Type, reflect.Value, error) {
+	row := &Row{Data: rowInterface.GetData()}
+	if headerRow, ok := rowInterface.(HeaderRow); ok {
+		row.header = headerRow.GetHeader()
+	}
+	if row.header != nil {
+		row.header.configure(opt)
+	}
+	name, err := row.lookup(d.Column)
+	if err != nil {
+		return nil, reflect.Value{}, err
+	}
+	value := ""
+	if val, ok := row.Data[name]; ok && val != nil {
+		value = strings.TrimSpace(fmt.Sprint(val))
+	}
+	typ, ok := d.types[value]
+	if !ok {
+		return nil, reflect.Value{}, &FieldError{
+			Code:   CodeUnknownType,
+			Column: d.Column,
+			Value:  value,
+		}
+	}
+	if target, ok := d.targets[value]; ok {
+		return typ, target, nil
+	}
+	if !defaultTarget.IsValid() {
+		return nil, reflect.Value{}, fmt.Errorf("no slice to append %s", typ)
+	}
+	if !typ.AssignableTo(defaultTarget.Type().Elem()) {
+		return nil, reflect.Value{}, fmt.Errorf("%s can not be appended to %s", typ, defaultTarget.Type())
+	}
+	return typ, defaultTarget, nil
+}
+
+// discriminatedItem 鉴别之后解码成功, 等待追加的对象
+type discriminatedItem struct {
+	target  reflect.Value
+	elem    reflect.Value
+	row     RowInterface
+	sources map[string]CellSource
+}
+
+// encodeDiscriminated 使用鉴别器解码, v可以为nil, 此时所有的类型都需要通过Bind绑定切片
+func encodeDiscriminated(parser Parser, v interface{}, d *Discriminator, opt ...interface{}) ([]error, encodeSummary, error) {
+	var errs []error
+	var summary encodeSummary
+	if d.err != nil {
+		return nil, summary, d.err
+	}
+	var defaultTarget reflect.Value
+	if v != nil {
+		value := reflect.ValueOf(v)
+		if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Slice {
+			return nil, summary, errors.New("v mast be []T type")
+		}
+		defaultTarget = value.Elem()
+	}
+	results, opt, summary, err := readResults(parser, d.columns(getNamingStrategy(opt)), opt)
+	if err != nil {
+		return nil, summary, err
+	}
+	policy := getErrorPolicy(opt)
+	locale := getLocale(opt)
+	checker := getRefChecker(opt)
+	provenance := getProvenance(opt)
+	var items []discriminatedItem
+	for _, r := range results {
+		if policy.reachLimit(errs) {
+			break
+		}
+		typ, target, err := d.target(r, defaultTarget, opt)
+		if err != nil {
+			errs = append(errs, WrapError(r.GetMetaInfo(), localizeError(err, locale)))
+			continue
+		}
+		var elem reflect.Value
+		if typ.Kind() == reflect.Ptr {
+			elem = reflect.New(typ.Elem())
+		} else {
+			elem = reflect.New(typ)
+		}
+		var sources map[string]CellSource
+		if provenance != nil {
+			sources = map[string]CellSource{}
+		}
+		if err := encodeResult(elem.Interface(), r, sources, opt...); err != nil {
+			errs = append(errs, WrapError(r.GetMetaInfo(), localizeError(err, locale)))
+			continue
+		}
+		if typ.Kind() != reflect.Ptr {
+			elem = elem.Elem()
+		}
+		items = append(items, discriminatedItem{target: target, elem: elem, row: r, sources: sources})
+		summary.ok++
+	}
+	if policy.Transactional && len(errs) > 0 {
+		// rolled back rows are reported as skipped
+		summary.ok = 0
+		return errs, summary, nil
+	}
+	for _, item := range items {
+		item.target.Set(reflect.Append(item.target, item.elem))
+		ptr := appendedPtr(item.target, item.elem)
+		if checker != nil {
+			checker.add(item.row.GetMetaInfo(), ptr, opt)
+		}
+		if provenance != nil {
+			provenance.Add(item.row.GetMetaInfo(), ptr, item.sources)
+		}
+	}
+	return errs, summary, nil
+}
+
+// appendedPtr 获取刚追加到切片末尾的对象的指针, 接口切片中的结构体值无法取地址时返回副本的指针
+func appendedPtr(target reflect.Value, elem reflect.Value) interface{} {
+	last := target.Index(target.Len() - 1)
+	if last.Kind() == reflect.Interface {
+		last = last.Elem()
+	}
+	if last.Kind() == reflect.Ptr {
+		return last.Interface()
+	}
+	if last.CanAddr() {
+		return last.Addr().Interface()
+	}
+	ptr := reflect.New(elem.Type())
+	ptr.Elem().Set(elem)
+	return ptr.Interface()
+}
+
+func getDiscriminator(opt []interface{}) *Discriminator {
+	for _, o := range opt {
+		if discriminator, ok := o.(*Discriminator); ok {
+			return discriminator
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package dorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fruitRow struct {
+	Name     string `dorm:"name:Name;required"`
+	Quantity int    `dorm:"name:Quantity"`
+}
+
+type toolRow struct {
+	Name  string `dorm:"name:Name"`
+	Brand string `dorm:"name:Brand"`
+}
+
+func TestDiscriminator(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "Stock", rows: [][]string{
+		{"Kind", "Name", "Quantity", "Brand"},
+		{"fruit", "Apple", "3", ""},
+		{"tool", "Hammer", "", "Acme"},
+		{"other", "?", "", ""},
+	}})
+	var tools []toolRow
+	discriminator := NewDiscriminator("Kind").
+		Register("fruit", &fruitRow{}).
+		Bind("tool", &tools)
+	var items []interface{}
+	err := mapper.Encode(&items, discriminator)
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("got %v, want one unknown type error", err)
+	}
+	if fieldErr, ok := errs[0].(*RowError).Err.(*FieldError); !ok || fieldErr.Code != CodeUnknownType {
+		t.Errorf("got %v, want %s", errs[0], CodeUnknownType)
+	}
+	if want := []interface{}{&fruitRow{Name: "Apple", Quantity: 3}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("got %v, want %v", items, want)
+	}
+	if want := []toolRow{{Name: "Hammer", Brand: "Acme"}}; !reflect.DeepEqual(tools, want) {
+		t.Errorf("got %v, want %v", tools, want)
+	}
+}
+
+func TestDiscriminatorHeaderPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  HeaderPolicy
+		header  []string
+		missing []string
+		unknown []string
+	}{
+		{name: "strict", policy: HeaderPolicy{Strict: true}, header: []string{"Kind", "Name", "Quantity", "Brand", "Color"}, unknown: []string{"Color"}},
+		{name: "columns of every type are known", policy: HeaderPolicy{Strict: true}, header: []string{"Kind", "Name", "Quantity", "Brand"}},
+		{name: "required column", header: []string{"Kind", "Quantity", "Brand"}, missing: []string{"Name"}},
+		{name: "discriminator column", header: []string{"Name", "Quantity"}, missing: []string{"Kind"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, testSheet{name: "Stock", rows: [][]string{tt.header}})
+			mapper.SetHeaderPolicy(tt.policy)
+			var tools []toolRow
+			var items []interface{}
+			discriminator := NewDiscriminator("Kind").Register("fruit", fruitRow{}).Bind("tool", &tools)
+			err := mapper.Encode(&items, discriminator)
+			if tt.missing == nil && tt.unknown == nil {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			headerErr, ok := err.(*HeaderError)
+			if !ok {
+				t.Fatalf("got %v, want *HeaderError", err)
+			}
+			if !reflect.DeepEqual(headerErr.Missing, tt.missing) || !reflect.DeepEqual(headerErr.Unknown, tt.unknown) {
+				t.Errorf("got missing %v unknown %v, want %v %v", headerErr.Missing, headerErr.Unknown, tt.missing, tt.unknown)
+			}
+		})
+	}
+}
+
+func TestDiscriminatorAutoBind(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "Stock", rows: [][]string{
+		{"Kind", "Name", "Quantty"},
+		{"fruit", "Apple", "3"},
+	}})
+	mapper.SetHeaderPolicy(HeaderPolicy{AutoBind: true})
+	var items []interface{}
+	if err := mapper.Encode(&items, NewDiscriminator("Kind").Register("fruit", fruitRow{})); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{fruitRow{Name: "Apple", Quantity: 3}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("got %v, want %v", items, want)
+	}
+	if len(mapper.GetWarnings()) != 1 {
+		t.Errorf("got warnings %v, want one", mapper.GetWarnings())
+	}
+}
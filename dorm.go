@@ -2,6 +2,7 @@ package dorm
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"reflect"
@@ -21,6 +22,24 @@ type Parser interface {
 	ReadToRows(opt ...interface{}) ([]RowInterface, error)
 }
 
+// HeaderParser 能在读取行数据之前提供表头的解析器
+type HeaderParser interface {
+	// ReadHeaders 读取每个sheet的表头
+	ReadHeaders(opt ...interface{}) ([]*Header, error)
+}
+
+// HeaderRow 能提供所属表头的行 同一表头的行共享列名的解析结果
+type HeaderRow interface {
+	// GetHeader 获取当前行所属的表头
+	GetHeader() *Header
+}
+
+// RowLocator 能提供行所在位置的元信息 自定义解析器的MetaInfo实现该接口后可以使用meta标签
+type RowLocator interface {
+	// Locate 获取sheet名和行号, 行号从0开始, meta:row字段填充的是行号加1
+	Locate() (sheet string, row string)
+}
+
 type ObjectMapper interface {
 	// SetParser 设置一个解析器
 	SetParser(parser Parser)
@@ -30,8 +49,18 @@ type ObjectMapper interface {
 
 // DocumentMapper 文档映射转换工具
 type DocumentMapper struct {
-	errs   []error
-	parser Parser
+	errs    []error
+	parser  Parser
+	policy  ErrorPolicy
+	locale  Locale
+	header  HeaderPolicy
+	summary encodeSummary
+
+	normalization Normalization
+	naming        NamingStrategy
+	bindings      []sheetBinding
+	sheetErrs     map[string][]error
+	selectors     []SheetSelector
 }
 
 // SetParser 设置一个解析器
@@ -39,16 +68,84 @@ func (mapper *DocumentMapper) SetParser(parser Parser) {
 	mapper.parser = parser
 }
 
+// SetErrorPolicy 设置编码时的错误处理策略
+func (mapper *DocumentMapper) SetErrorPolicy(policy ErrorPolicy) {
+	mapper.policy = policy
+}
+
+// SetHeaderPolicy 设置表头检查策略
+func (mapper *DocumentMapper) SetHeaderPolicy(policy HeaderPolicy) {
+	mapper.header = policy
+}
+
+// SetNormalization 设置表头的规范化流水线
+func (mapper *DocumentMapper) SetNormalization(normalization Normalization) {
+	mapper.normalization = normalization
+}
+
+// SetNamingStrategy 设置没有dorm name的字段推导列名的策略
+func (mapper *DocumentMapper) SetNamingStrategy(strategy NamingStrategy) {
+	mapper.naming = strategy
+}
+
+// SetSheetSelector 设置选择sheet的选择器, 和解析器上的选择器同时生效
+func (mapper *DocumentMapper) SetSheetSelector(selectors ...SheetSelector) {
+	mapper.selectors = selectors
+}
+
+// SetLocale 设置错误信息的语言
+func (mapper *DocumentMapper) SetLocale(locale Locale) {
+	mapper.locale = locale
+}
+
 // GetErrors 获取解析中发生的错误
 func (mapper *DocumentMapper) GetErrors() []error {
 	return mapper.errs
 }
 
-// Encode 将文档编码为指定的对象
+// GetWarnings 获取解析中产生的警告, 例如表头的自动绑定
+func (mapper *DocumentMapper) GetWarnings() []error {
+	return mapper.summary.warnings
+}
+
+// Encode 将文档编码为指定的对象 有行错误时返回包装了GetErrors()的Errors
 func (mapper *DocumentMapper) Encode(v interface{}, opt ...interface{}) error {
-	errs, err := EncodeByParser(mapper.parser, v, opt...)
+	errs, summary, err := encodeByParser(mapper.parser, v, mapper.options(opt)...)
 	mapper.errs = errs
-	return err
+	mapper.summary = summary
+	if err != nil {
+		switch e := err.(type) {
+		case *HeaderError:
+			mapper.errs = []error{e}
+		case Errors:
+			mapper.errs = e
+		}
+		return err
+	}
+	if len(errs) > 0 {
+		return Errors(errs)
+	}
+	return nil
+}
+
+// options 在调用方的opt之后追加mapper上的配置, 调用方传入的配置优先
+func (mapper *DocumentMapper) options(opt []interface{}) []interface{} {
+	options := make([]interface{}, 0, len(opt)+5+len(mapper.selectors))
+	options = append(options, opt...)
+	options = append(options, mapper.policy, mapper.header)
+	if mapper.locale != "" {
+		options = append(options, mapper.locale)
+	}
+	if mapper.normalization != nil {
+		options = append(options, mapper.normalization)
+	}
+	if mapper.naming != nil {
+		options = append(options, mapper.naming)
+	}
+	for _, selector := range mapper.selectors {
+		options = append(options, selector)
+	}
+	return options
 }
 
 // OpenXlsFile 打开excel文件并使用默认的excel解析方式
@@ -75,29 +172,62 @@ func OpenReader(file io.Reader) (*DocumentMapper, error) {
 	return mapper, nil
 }
 
+// encodeSummary 编码的行数统计和警告
+type encodeSummary struct {
+	// total 读取到的行数
+	total int
+	// ok 编码成功的行数
+	ok int
+	// warnings 表头自动绑定等不影响结果的警告
+	warnings []error
+}
+
 // EncodeByParser 使用parser解析文档为对象
 func EncodeByParser(parser Parser, v interface{}, opt ...interface{}) ([]error, error) {
+	errs, _, err := encodeByParser(parser, v, opt...)
+	return errs, err
+}
+
+func encodeByParser(parser Parser, v interface{}, opt ...interface{}) ([]error, encodeSummary, error) {
 	var errs []error
+	var summary encodeSummary
+	if discriminator := getDiscriminator(opt); discriminator != nil {
+		return encodeDiscriminated(parser, v, discriminator, opt...)
+	}
 	value := reflect.ValueOf(v)
 	if !value.IsValid() {
-		return nil, errors.New("interface not valid")
+		return nil, summary, errors.New("interface not valid")
 	}
 
 	kind := reflect.TypeOf(v).Kind()
 	if kind != reflect.Ptr {
-		return nil, errors.New("v must be ptr")
-	}
-	results, err := parser.ReadToRows()
-	if err != nil {
-		return nil, err
+		return nil, summary, errors.New("v must be ptr")
 	}
 	reflectValue := reflect.ValueOf(v).Elem()
+	if reflectValue.Kind() == reflect.Struct {
+		return encodeSingle(parser, reflectValue, opt...)
+	}
 	if reflectValue.Kind() != reflect.Slice {
-		return nil, errors.New("v mast be []*T type")
+		return nil, summary, errors.New("v mast be []*T type")
+	}
+	specs := typeColumns(reflectValue.Type().Elem(), []string{""}, getNamingStrategy(opt))
+	results, opt, summary, err := readResults(parser, specs, opt)
+	if err != nil {
+		return nil, summary, err
 	}
+	policy := getErrorPolicy(opt)
+	locale := getLocale(opt)
+	checker := getRefChecker(opt)
+	provenance := getProvenance(opt)
 	var elem reflect.Value
+	var encoded []RowInterface
+	var sources []map[string]CellSource
+	items := reflect.MakeSlice(reflectValue.Type(), 0, len(results))
 	isStruct := reflectValue.Type().Elem().Kind() == reflect.Struct
 	for _, r := range results {
+		if policy.reachLimit(errs) {
+			break
+		}
 		if isStruct {
 			elem = reflect.New(reflectValue.Type().Elem())
 		} else {
@@ -105,25 +235,128 @@ func EncodeByParser(parser Parser, v interface{}, opt ...interface{}) ([]error,
 			elem.Set(reflect.New(elem.Type().Elem()))
 		}
 		itemInterface := elem.Interface()
-		if err := encodeResult(itemInterface, r, opt...); err != nil {
-			errs = append(errs, WrapError(r.GetMetaInfo(), err))
+		var source map[string]CellSource
+		if provenance != nil {
+			source = map[string]CellSource{}
+		}
+		if err := encodeResult(itemInterface, r, source, opt...); err != nil {
+			errs = append(errs, WrapError(r.GetMetaInfo(), localizeError(err, locale)))
 			continue
 		}
+		encoded = append(encoded, r)
+		sources = append(sources, source)
+		summary.ok++
 		if isStruct {
-			reflectValue.Set(reflect.Append(reflectValue, elem.Elem()))
+			items = reflect.Append(items, elem.Elem())
 		} else {
-			reflectValue.Set(reflect.Append(reflectValue, elem))
+			items = reflect.Append(items, elem)
 		}
 	}
-	return errs, nil
+	if policy.Transactional && len(errs) > 0 {
+		// rolled back rows are reported as skipped
+		summary.ok = 0
+		return errs, summary, nil
+	}
+	start := reflectValue.Len()
+	reflectValue.Set(reflect.AppendSlice(reflectValue, items))
+	if checker != nil {
+		for i, r := range encoded {
+			checker.add(r.GetMetaInfo(), itemPtr(reflectValue.Index(start+i)), opt)
+		}
+	}
+	if provenance != nil {
+		for i, r := range encoded {
+			provenance.Add(r.GetMetaInfo(), itemPtr(reflectValue.Index(start+i)), sources[i])
+		}
+	}
+	return errs, summary, nil
 }
 
-func encodeResult(v interface{}, rowInterface RowInterface, opt ...interface{}) error {
+// readResults 按目标类型映射的列检查表头之后读取所有行, 返回追加了表头自动绑定的opt
+func readResults(parser Parser, specs []columnSpec, opt []interface{}) ([]RowInterface, []interface{}, encodeSummary, error) {
+	var summary encodeSummary
+	if headerParser, ok := parser.(HeaderParser); ok {
+		bindings, warnings, err := checkHeaders(headerParser, specs, opt...)
+		summary.warnings = warnings
+		if err != nil {
+			return nil, opt, summary, err
+		}
+		if len(bindings) > 0 {
+			opt = append(opt[:len(opt):len(opt)], bindings)
+		}
+	}
+	results, err := parser.ReadToRows(opt...)
+	if err != nil {
+		return nil, opt, summary, err
+	}
+	summary.total = len(results)
+	return results, opt, summary, nil
+}
+
+// encodeSingle 将唯一的一条记录直接编码到结构体中, 用于纵向布局和表单布局的配置sheet
+// 在结构体的副本上编码, 成功之后才写回, 出错时结构体保持不变; 没有记录时结构体保持不变, 有多条记录时返回错误
+func encodeSingle(parser Parser, reflectValue reflect.Value, opt ...interface{}) ([]error, encodeSummary, error) {
+	specs := typeColumns(reflectValue.Type(), []string{""}, getNamingStrategy(opt))
+	results, opt, summary, err := readResults(parser, specs, opt)
+	if err != nil {
+		return nil, summary, err
+	}
+	switch len(results) {
+	case 0:
+		return nil, summary, nil
+	case 1:
+	default:
+		return nil, summary, fmt.Errorf("%d records found, a single struct can only hold one", len(results))
+	}
+	r := results[0]
+	decoded := reflect.New(reflectValue.Type())
+	decoded.Elem().Set(reflectValue)
+	var source map[string]CellSource
+	provenance := getProvenance(opt)
+	if provenance != nil {
+		source = map[string]CellSource{}
+	}
+	if err := encodeResult(decoded.Interface(), r, source, opt...); err != nil {
+		return []error{WrapError(r.GetMetaInfo(), localizeError(err, getLocale(opt)))}, summary, nil
+	}
+	reflectValue.Set(decoded.Elem())
+	item := reflectValue.Addr().Interface()
+	summary.ok++
+	if checker := getRefChecker(opt); checker != nil {
+		checker.add(r.GetMetaInfo(), item, opt)
+	}
+	if provenance != nil {
+		provenance.Add(r.GetMetaInfo(), item, source)
+	}
+	return nil, summary, nil
+}
+
+// itemPtr 获取切片元素对应的结构体指针
+func itemPtr(item reflect.Value) interface{} {
+	if item.Kind() == reflect.Ptr {
+		return item.Interface()
+	}
+	return item.Addr().Interface()
+}
+
+// encodeResult 将一行编码为对象 sources不为nil时记录各字段来源的单元格
+func encodeResult(v interface{}, rowInterface RowInterface, sources map[string]CellSource, opt ...interface{}) error {
 	data := rowInterface.GetData()
 	row := &Row{
 		Data:     data,
 		MetaInfo: rowInterface.GetMetaInfo(),
+		sources:  sources,
+	}
+	if headerRow, ok := rowInterface.(HeaderRow); ok {
+		row.header = headerRow.GetHeader()
 	}
+	if cellReader, ok := rowInterface.(CellReader); ok {
+		row.cells = cellReader
+	}
+	if row.header == nil {
+		row.header = dataHeader(data, nil)
+	}
+	row.header.configure(opt)
 	if encoder, ok := v.(Encoder); ok {
 		if err := encoder.EncodeDocument(row, opt...); err != nil {
 			return err
@@ -136,12 +369,15 @@ func encodeResult(v interface{}, rowInterface RowInterface, opt ...interface{})
 	return nil
 }
 
-// WriteToExcelFile 将对象写入到excel的指定的sheet中
-func WriteToExcelFile(writer io.Writer, sheetName string, v interface{}) error {
+// WriteToExcelFile 将对象写入到excel的指定的sheet中 opt中可以传入HeaderLocale指定表头使用的语言
+func WriteToExcelFile(writer io.Writer, sheetName string, v interface{}, opt ...interface{}) error {
 	var err error
 	var nameValues []map[string]interface{}
 	var nameValue map[string]interface{}
 	var nameSorts []string
+	// 各条记录的列可能不同(extra和map字段), 表头为所有记录的列按首次出现的顺序合并
+	var titleSorts []string
+	seen := map[string]bool{}
 
 	value := reflect.ValueOf(v)
 	if !value.IsValid() {
@@ -164,16 +400,22 @@ func WriteToExcelFile(writer io.Writer, sheetName string, v interface{}) error {
 				return err
 			}
 		} else {
-			nameValue, nameSorts, err = DecodeDocument(vi)
+			nameValue, nameSorts, err = DecodeDocument(vi, opt...)
 			if err != nil {
 				return err
 			}
 		}
 		nameValues = append(nameValues, nameValue)
+		for _, name := range nameSorts {
+			if !seen[name] {
+				seen[name] = true
+				titleSorts = append(titleSorts, name)
+			}
+		}
 	}
 
 	titles := map[string]interface{}{}
-	for _, name := range nameSorts {
+	for _, name := range titleSorts {
 		titles[name] = name
 	}
 
@@ -183,8 +425,8 @@ func WriteToExcelFile(writer io.Writer, sheetName string, v interface{}) error {
 	}
 
 	serializer := NewExcelSerializer()
-	serializer.Serialize(nameSorts, 1, sheetName, titleValues)
-	serializer.Serialize(nameSorts, 2, sheetName, nameValues)
+	serializer.Serialize(titleSorts, 1, sheetName, titleValues)
+	serializer.Serialize(titleSorts, 2, sheetName, nameValues)
 	_, err = serializer.WriteToFile(writer)
 	return err
 }
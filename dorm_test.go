@@ -0,0 +1,78 @@
+package dorm
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+)
+
+// testSheet 测试用的sheet, 空字符串的单元格不写入
+type testSheet struct {
+	name string
+	rows [][]string
+}
+
+// newWorkbook 在内存中创建excel文件, 第一个sheet替换默认的Sheet1
+func newWorkbook(sheets ...testSheet) *excelize.File {
+	file := excelize.NewFile()
+	for i, sheet := range sheets {
+		if i == 0 {
+			file.SetSheetName("Sheet1", sheet.name)
+		} else {
+			file.NewSheet(sheet.name)
+		}
+		for r, row := range sheet.rows {
+			for c, cell := range row {
+				if cell != "" {
+					file.SetCellValue(sheet.name, ColumnLetter(c+1)+strconv.Itoa(r+1), cell)
+				}
+			}
+		}
+	}
+	return file
+}
+
+// openWorkbook 将内存中的excel文件作为DocumentMapper打开
+func openWorkbook(t *testing.T, file *excelize.File) *DocumentMapper {
+	t.Helper()
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapper, err := OpenReader(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mapper
+}
+
+// openSheets 创建包含指定sheet的excel文件并打开
+func openSheets(t *testing.T, sheets ...testSheet) *DocumentMapper {
+	t.Helper()
+	return openWorkbook(t, newWorkbook(sheets...))
+}
+
+// excelParser 获取mapper使用的ExcelParser
+func excelParser(mapper *DocumentMapper) *ExcelParser {
+	return mapper.parser.(*ExcelParser)
+}
+
+// withDefinedNames 在excel文件的workbook.xml中加入定义的名称, names为definedName元素
+func withDefinedNames(t *testing.T, file *excelize.File, names ...string) *excelize.File {
+	t.Helper()
+	buffer, err := file.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err = excelize.OpenReader(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	definedNames := "<definedNames>" + strings.Join(names, "") + "</definedNames>"
+	workbook := string(file.XLSX["xl/workbook.xml"])
+	file.XLSX["xl/workbook.xml"] = []byte(strings.Replace(workbook, "</sheets>", "</sheets>"+definedNames, 1))
+	file.WorkBook = nil
+	return file
+}
@@ -1,9 +1,12 @@
 package dorm
 
 import (
-	"go/ast"
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"regexp/syntax"
 	"sort"
+	"strings"
 )
 
 // Decoder 解码器
@@ -48,45 +51,84 @@ func (k WeightKeys) GetKeys() []string {
 	return keys
 }
 
-// DecodeDocument 解码对象到map
-func DecodeDocument(v interface{}) (map[string]interface{}, []string, error) {
+// DecodeDocument 解码对象到map opt中可以传入HeaderLocale指定表头使用的语言
+func DecodeDocument(v interface{}, opt ...interface{}) (map[string]interface{}, []string, error) {
 	var keySort []string
+	locale := getHeaderLocale(opt)
 	var weightKeys WeightKeys
 	typ := reflect.TypeOf(v)
 	reflectType := typ.Elem()
 	reflectValue := reflect.ValueOf(v).Elem()
 	result := map[string]interface{}{}
-	for i := 0; i < reflectType.NumField(); i++ {
-		if fieldStruct := reflectType.Field(i); ast.IsExported(fieldStruct.Name) {
-			kind := reflectValue.Field(i).Kind()
-			field := &Field{
-				StructField: &StructField{
-					Struct:      fieldStruct,
-					Name:        fieldStruct.Name,
-					Tag:         fieldStruct.Tag,
-					TagSettings: parseTagSetting(fieldStruct.Tag),
-				},
-				Field: reflectValue.Field(i),
-			}
+	var extras []*Field
+	for _, fieldStruct := range structFields(reflectType) {
+		if fieldValue, ok := fieldByIndex(reflectValue, fieldStruct.Index, false); ok {
+			kind := fieldValue.Kind()
+			field := newField(fieldStruct, fieldValue, opt)
 			// is ignored field
 			if _, ok := field.TagSettingsGet("-"); ok {
 				continue
 			}
+			// meta field is filled from the row and never written
+			if _, ok := field.TagSettingsGet(metaTag); ok {
+				continue
+			}
+			// cell field is written by DecodeCells
+			if _, ok := field.TagSettingsGet(cellTag); ok {
+				continue
+			}
+			// extra field is decoded after all named columns
+			if _, ok := field.TagSettingsGet(extraTag); ok {
+				extras = append(extras, field)
+				continue
+			}
+			if _, ok := field.TagSettingsGet(jsonTag); ok {
+				if name, ok := field.decodeName(locale); ok {
+					name = primaryName(name)
+					weight, _ := field.TagSettingsGet("INDEX")
+					weightKeys = append(weightKeys, WeightKey{
+						Key:    name,
+						weight: weight,
+					})
+					text, err := marshalJSON(field.Field)
+					if err != nil {
+						return nil, nil, err
+					}
+					result[name] = text
+				}
+				continue
+			}
+			if kind == reflect.Ptr && !field.isStruct() {
+				kind = field.Struct.Type.Elem().Kind()
+			}
 			switch kind {
 			case reflect.Ptr, reflect.Struct:
 				var err error
-				result, err = decodeDecodeDocumentStruct(kind, field, result)
+				result, err = decodeDecodeDocumentStruct(kind, field, result, opt...)
 				if err != nil {
 					return nil, nil, err
 				}
+			case reflect.Map:
+				weight, _ := field.TagSettingsGet("INDEX")
+				for _, key := range decodeMap(field, result, locale) {
+					weightKeys = append(weightKeys, WeightKey{
+						Key:    key,
+						weight: weight,
+					})
+				}
 			default:
-				if name, ok := field.TagSettingsGet("NAME"); ok {
+				if name, ok := field.decodeName(locale); ok {
+					name = primaryName(name)
 					weight, _ := field.TagSettingsGet("INDEX")
 					weightKeys = append(weightKeys, WeightKey{
 						Key:    name,
 						weight: weight,
 					})
-					result[name] = field.Field.Interface()
+					if sep, ok := field.separator(); ok {
+						result[name] = joinSeparated(field.Field, sep)
+					} else {
+						result[name] = indirectInterface(field.Field)
+					}
 				}
 			}
 		}
@@ -95,11 +137,129 @@ func DecodeDocument(v interface{}) (map[string]interface{}, []string, error) {
 		sort.Sort(weightKeys)
 		keySort = weightKeys.GetKeys()
 	}
+	var extraKeys []string
+	for _, field := range extras {
+		extraKeys = append(extraKeys, decodeExtra(field, result)...)
+	}
+	if len(extraKeys) > 0 {
+		sort.Strings(extraKeys)
+		keySort = append(keySort, extraKeys...)
+	}
 	return result, keySort, nil
 }
 
+// decodeExtra 将extra字段中的数据作为额外的列写入结果, 返回写入的列名 已经写入的列被忽略
+func decodeExtra(field *Field, result map[string]interface{}) []string {
+	var keys []string
+	if field.Field.Kind() != reflect.Map || field.Field.Type().Key().Kind() != reflect.String {
+		return keys
+	}
+	iter := field.Field.MapRange()
+	for iter.Next() {
+		key := iter.Key().String()
+		if _, ok := result[key]; ok {
+			continue
+		}
+		result[key] = iter.Value().Interface()
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// marshalJSON 将字段序列化为JSON, 空指针和空map等序列化为空字符串
+func marshalJSON(value reflect.Value) (string, error) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if value.IsNil() {
+			return "", nil
+		}
+	}
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// joinSeparated 将切片的元素用分隔符拼接为一个单元格
+func joinSeparated(value reflect.Value, sep string) string {
+	var parts []string
+	for i := 0; i < value.Len(); i++ {
+		item := indirectInterface(value.Index(i))
+		if item == nil {
+			continue
+		}
+		parts = append(parts, fmt.Sprint(item))
+	}
+	return strings.Join(parts, sep)
+}
+
+// decodeMap 将map字段展开为多列, 返回按key排序的列名
+func decodeMap(field *Field, result map[string]interface{}, locale Locale) []string {
+	var columns []string
+	name, ok := field.decodeName(locale)
+	if !ok || field.Field.IsNil() {
+		return columns
+	}
+	isReg, _ := field.TagSettingsGet(regTag)
+	if isReg != "true" {
+		name = primaryName(name)
+	}
+	var keys []string
+	values := map[string]interface{}{}
+	iter := field.Field.MapRange()
+	for iter.Next() {
+		key := fmt.Sprint(iter.Key().Interface())
+		keys = append(keys, key)
+		values[key] = iter.Value().Interface()
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		column := strings.Split(name, "-")[0] + "-" + key
+		if isReg == "true" {
+			column = expandPattern(name, key)
+		}
+		result[column] = values[key]
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// expandPattern 将正则中的key分组替换为key得到列名, 正则中除分组之外只能是普通字符, 否则直接使用key
+func expandPattern(pattern, key string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return key
+	}
+	var subs []*syntax.Regexp
+	switch re.Op {
+	case syntax.OpConcat:
+		subs = re.Sub
+	default:
+		subs = []*syntax.Regexp{re}
+	}
+	column := ""
+	replaced := false
+	for _, sub := range subs {
+		switch {
+		case sub.Op == syntax.OpLiteral:
+			column += string(sub.Rune)
+		case sub.Op == syntax.OpCapture && !replaced && (sub.Name == "key" || sub.Name == ""):
+			column += key
+			replaced = true
+		case sub.Op == syntax.OpBeginText || sub.Op == syntax.OpEndText:
+		default:
+			return key
+		}
+	}
+	if !replaced {
+		return key
+	}
+	return column
+}
+
 func decodeDecodeDocumentStruct(kind reflect.Kind, field *Field,
-	result map[string]interface{}) (map[string]interface{}, error){
+	result map[string]interface{}, opt ...interface{}) (map[string]interface{}, error){
 	isPtr := kind == reflect.Ptr
 	if isPtr && field.Field.IsNil() {
 		return result, nil
@@ -117,15 +277,27 @@ func decodeDecodeDocumentStruct(kind reflect.Kind, field *Field,
 			return result, err
 		}
 	} else {
-		subResult, _, err = DecodeDocument(fieldInterface)
+		subResult, _, err = DecodeDocument(fieldInterface, opt...)
 		if err != nil {
 			return result, err
 		}
 	}
-	if name, ok := field.TagSettingsGet("NAME"); ok {
+	if name, ok := field.decodeName(getHeaderLocale(opt)); ok {
+		name = primaryName(name)
 		for key, val := range subResult {
 			result[name+key] = val
 		}
 	}
 	return result, nil
-}
\ No newline at end of file
+}
+
+// indirectInterface 获取指针指向的值, 空指针返回nil
+func indirectInterface(value reflect.Value) interface{} {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		return value.Elem().Interface()
+	}
+	return value.Interface()
+}
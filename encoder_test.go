@@ -0,0 +1,91 @@
+package dorm
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+)
+
+type extraFirstItem struct {
+	Extra map[string]string `dorm:"extra"`
+	Name  string            `dorm:"name:名称"`
+}
+
+func TestDecodeExtraAfterNamedFields(t *testing.T) {
+	item := &extraFirstItem{
+		Extra: map[string]string{"名称": "旧名称", "颜色": "红"},
+		Name:  "苹果",
+	}
+	result, keys, err := DecodeDocument(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"名称", "颜色"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got keys %v, want %v", keys, want)
+	}
+	if result["名称"] != "苹果" {
+		t.Errorf("got 名称 %v, want the named field value", result["名称"])
+	}
+}
+
+func TestExtraRoundTrip(t *testing.T) {
+	items := []*extraFirstItem{
+		{Extra: map[string]string{"名称": "旧名称", "颜色": "红", "产地": "山东"}, Name: "苹果"},
+	}
+	var buffer bytes.Buffer
+	if err := WriteToExcelFile(&buffer, "Sheet1", items); err != nil {
+		t.Fatal(err)
+	}
+	data := buffer.Bytes()
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := file.GetRows("Sheet1")[0]
+	if want := []string{"名称", "产地", "颜色"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("got header %v, want %v", header, want)
+	}
+	mapper, err := OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []*extraFirstItem
+	if err := mapper.Encode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	want := &extraFirstItem{Extra: map[string]string{"颜色": "红", "产地": "山东"}, Name: "苹果"}
+	if len(decoded) != 1 || !reflect.DeepEqual(decoded[0], want) {
+		t.Errorf("got %+v, want %+v", decoded, want)
+	}
+}
+
+// writeAndRead 写入excel之后读取Sheet1的所有行
+func writeAndRead(t *testing.T, v interface{}, opt ...interface{}) [][]string {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := WriteToExcelFile(&buffer, "Sheet1", v, opt...); err != nil {
+		t.Fatal(err)
+	}
+	file, err := excelize.OpenReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file.GetRows("Sheet1")
+}
+
+func TestWriteExtraKeysOfAllRecords(t *testing.T) {
+	items := []*extraFirstItem{
+		{Extra: map[string]string{"颜色": "红"}, Name: "a"},
+		{Extra: map[string]string{"尺寸": "大"}, Name: "b"},
+	}
+	want := [][]string{
+		{"名称", "颜色", "尺寸"},
+		{"a", "红", ""},
+		{"b", "", "大"},
+	}
+	if rows := writeAndRead(t, items); !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %v, want %v", rows, want)
+	}
+}
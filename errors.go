@@ -3,6 +3,7 @@ package dorm
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -12,6 +13,7 @@ var (
 type RowError struct {
 	MetaInfo  interface{}
 	ErrorInfo string
+	Err       error
 }
 
 func NewRowError(metaInfo interface{}, text string) error {
@@ -25,9 +27,67 @@ func WrapError(metaInfo interface{}, err error) error {
 	return &RowError{
 		MetaInfo:  metaInfo,
 		ErrorInfo: err.Error(),
+		Err:       err,
 	}
 }
 
 func (e *RowError) Error() string {
 	return fmt.Sprintf("%v, error:%s", e.MetaInfo, e.ErrorInfo)
 }
+
+// Unwrap 返回被包装的错误
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Errors 编码过程中产生的错误集合
+type Errors []error
+
+func (errs Errors) Error() string {
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap 返回所有被包装的错误
+func (errs Errors) Unwrap() []error {
+	return errs
+}
+
+// ErrorPolicy 编码时的错误处理策略
+type ErrorPolicy struct {
+	// MaxErrors 错误数达到该值后停止编码, 0表示不限制
+	MaxErrors int
+	// Transactional 为true时只要有一行出错, 结果切片保持不变
+	Transactional bool
+}
+
+var (
+	// CollectAll 收集全部错误, 正确的行依然写入结果, 默认策略
+	CollectAll = ErrorPolicy{}
+	// FailFast 遇到第一个错误即停止
+	FailFast = ErrorPolicy{MaxErrors: 1}
+	// AllOrNothing 收集全部错误, 有任何一行出错时结果切片保持不变
+	AllOrNothing = ErrorPolicy{Transactional: true}
+)
+
+// MaxErrors 错误数达到n之后停止编码
+func MaxErrors(n int) ErrorPolicy {
+	return ErrorPolicy{MaxErrors: n}
+}
+
+// reachLimit 错误数是否已经达到上限
+func (policy ErrorPolicy) reachLimit(errs []error) bool {
+	return policy.MaxErrors > 0 && len(errs) >= policy.MaxErrors
+}
+
+func getErrorPolicy(opt []interface{}) ErrorPolicy {
+	for _, o := range opt {
+		if policy, ok := o.(ErrorPolicy); ok {
+			return policy
+		}
+	}
+	return CollectAll
+}
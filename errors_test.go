@@ -0,0 +1,75 @@
+package dorm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func policySheet() testSheet {
+	return testSheet{name: "库存", rows: [][]string{
+		{"名称", "数量"},
+		{"a", "1"},
+		{"b", "x"},
+		{"c", "3"},
+		{"d", "y"},
+	}}
+}
+
+func TestErrorPolicy(t *testing.T) {
+	existing := &reportItem{Name: "已有"}
+	tests := []struct {
+		name   string
+		policy ErrorPolicy
+		items  []string
+		rows   []string
+	}{
+		{name: "collect all", policy: CollectAll, items: []string{"已有", "a", "c"}, rows: []string{"2", "4"}},
+		{name: "fail fast", policy: FailFast, items: []string{"已有", "a"}, rows: []string{"2"}},
+		{name: "max errors", policy: MaxErrors(1), items: []string{"已有", "a"}, rows: []string{"2"}},
+		{name: "all or nothing", policy: AllOrNothing, items: []string{"已有"}, rows: []string{"2", "4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, policySheet())
+			mapper.SetErrorPolicy(tt.policy)
+			items := []*reportItem{existing}
+			err := mapper.Encode(&items)
+			if _, ok := err.(Errors); !ok {
+				t.Fatalf("got %T, want Errors", err)
+			}
+			var names []string
+			for _, item := range items {
+				names = append(names, item.Name)
+			}
+			if !reflect.DeepEqual(names, tt.items) {
+				t.Errorf("got items %v, want %v", names, tt.items)
+			}
+			var rows []string
+			for _, rowErr := range mapper.GetErrors() {
+				_, row := locate(rowErr.(*RowError).MetaInfo)
+				rows = append(rows, row)
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("got error rows %v, want %v", rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestErrorsUnwrap(t *testing.T) {
+	mapper := openSheets(t, policySheet())
+	var items []*reportItem
+	err := mapper.Encode(&items, FailFast)
+	var rowErr *RowError
+	if !errors.As(err, &rowErr) {
+		t.Fatalf("got %v, want a *RowError inside", err)
+	}
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("got %v, want a *FieldError inside", err)
+	}
+	if fieldErr.Code != CodeConvertFailed || fieldErr.Column != "数量" || fieldErr.Value != "x" {
+		t.Errorf("got %+v", fieldErr)
+	}
+}
@@ -2,6 +2,7 @@ package dorm
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
@@ -11,20 +12,52 @@ import (
 
 // ExcelParser excel解析器 实现了Parser接口
 type ExcelParser struct {
-	sheetName string
-	file      *excelize.File
+	sheetName  string
+	selectors  []SheetSelector
+	layout     Layout
+	split      *TableSplit
+	namedRange NamedRange
+	file       *excelize.File
 }
 
 // MetaInfo excel的元信息
 type MetaInfo struct {
 	SheetName string
-	RowIndex  string
+	// RowIndex 记录在sheet中的位置, 从0开始, 即excel中的行号减1, 所有布局和表格拆分方式都相同
+	// 横向布局时表头所在的第一行为0, 没有表头时第一行数据为0, 纵向布局时为记录所在列的序号(A列为0), 表单布局时为0
+	RowIndex string
+	// TableIndex 一个sheet拆分为多个表格时表格的序号, 从1开始, 没有拆分时为0
+	TableIndex int
+	// TableTitle 表格的标题行, 读取NamedRange时为区域的名称
+	TableTitle string
+}
+
+func (info MetaInfo) String() string {
+	if info.TableIndex == 0 {
+		return fmt.Sprintf("{%s %s}", info.SheetName, info.RowIndex)
+	}
+	return fmt.Sprintf("{%s %s %d %s}", info.SheetName, info.RowIndex, info.TableIndex, info.TableTitle)
+}
+
+// Locate 获取sheet名和行号
+func (info MetaInfo) Locate() (string, string) {
+	return info.SheetName, info.RowIndex
+}
+
+// locate 从元信息中获取sheet名和行号
+func locate(metaInfo interface{}) (string, string) {
+	if locator, ok := metaInfo.(RowLocator); ok {
+		return locator.Locate()
+	}
+	return "", ""
 }
 
 // ExcelRow excel行信息
 type ExcelRow struct {
 	data     map[string]interface{}
 	metaInfo MetaInfo
+	header   *Header
+	parser   *ExcelParser
 }
 
 // GetData 获取到当前行的数据
@@ -37,6 +70,11 @@ func (m *ExcelRow) GetMetaInfo() interface{} {
 	return m.metaInfo
 }
 
+// GetHeader 获取当前行所属的表头
+func (m *ExcelRow) GetHeader() *Header {
+	return m.header
+}
+
 // NewExcelParser 通过文件reader实例化一个ExcelParser
 func NewExcelParser(r io.Reader) (*ExcelParser, error) {
 	xlsFile, err := excelize.OpenReader(r)
@@ -52,48 +90,175 @@ func (p *ExcelParser) SetSheetName(sheetName string) {
 	p.sheetName = sheetName
 }
 
-func (p *ExcelParser) rowsToResults(sheetName string, columns [][]string, rows []RowInterface) []RowInterface {
-	titleIndex := map[int]string{}
-	for index, column := range columns {
+// tableHeader 表格第一行的表头
+func tableHeader(table sheetTable) *Header {
+	header := NewHeader(table.sheetName, nil)
+	header.TableIndex = table.index
+	header.column = table.column
+	if len(table.rows) > 0 {
+		for _, colCell := range table.rows[0] {
+			header.Columns = append(header.Columns, strings.TrimSpace(colCell))
+		}
+	}
+	return header
+}
+
+func (p *ExcelParser) rowsToResults(table sheetTable, header *Header, rows []RowInterface) []RowInterface {
+	if table.form {
+		return append(rows, &ExcelRow{
+			data: map[string]interface{}{},
+			metaInfo: MetaInfo{
+				SheetName:  table.sheetName,
+				RowIndex:   "0",
+				TableIndex: table.index,
+				TableTitle: table.title,
+			},
+			header: header,
+			parser: p,
+		})
+	}
+	for index, column := range table.rows {
 		if index == 0 {
-			for rowIndex, colCell := range column {
-				titleIndex[rowIndex] = strings.TrimSpace(colCell)
-			}
-		} else {
-			rowData := map[string]interface{}{}
-			for rowIndex, colCell := range column {
-				titleName := titleIndex[rowIndex]
-				rowData[titleName] = colCell
-			}
-			cellResult := &ExcelRow{
-				data: rowData,
-				metaInfo: MetaInfo{
-					SheetName: sheetName,
-					RowIndex:  strconv.Itoa(index),
-				},
+			continue
+		}
+		rowData := map[string]interface{}{}
+		for rowIndex, colCell := range column {
+			titleName := ""
+			if rowIndex < len(header.Columns) {
+				titleName = header.Columns[rowIndex]
 			}
-			rows = append(rows, cellResult)
+			rowData[titleName] = colCell
 		}
+		cellResult := &ExcelRow{
+			data: rowData,
+			metaInfo: MetaInfo{
+				SheetName:  table.sheetName,
+				RowIndex:   strconv.Itoa(table.lines[index]),
+				TableIndex: table.index,
+				TableTitle: table.title,
+			},
+			header: header,
+			parser: p,
+		}
+		rows = append(rows, cellResult)
 	}
 	return rows
 }
 
-// ReadToRows 读取并解析道行数据列表
-func (p *ExcelParser) ReadToRows(opt ...interface{}) ([]RowInterface, error) {
-	var rows []RowInterface
+// SetLayout 设置sheet中数据的布局, opt中传入的Layout优先
+func (p *ExcelParser) SetLayout(layout Layout) {
+	p.layout = layout
+}
+
+// SetTableSplit 设置将一个sheet拆分为多个表格的方式, opt中传入的TableSplit优先
+func (p *ExcelParser) SetTableSplit(split TableSplit) {
+	p.split = &split
+}
+
+// readTables 读取sheet中的表格并按布局转换, 每个表格的第一行为表头
+func (p *ExcelParser) readTables(sheetName string, opt []interface{}) []sheetTable {
+	layout, ok := getLayout(opt)
+	if !ok {
+		layout = p.layout
+	}
+	if layout == LayoutForm {
+		return []sheetTable{layout.arrange(sheetTable{sheetName: sheetName})}
+	}
+	sheetRows := p.file.GetRows(sheetName)
+	split, ok := getTableSplit(opt)
+	if !ok && p.split != nil {
+		split, ok = *p.split, true
+	}
+	if !ok {
+		return []sheetTable{layout.arrange(wholeSheet(sheetName, sheetRows))}
+	}
+	tables := split.split(sheetName, sheetRows)
+	for i := range tables {
+		tables[i] = layout.arrange(tables[i])
+	}
+	return tables
+}
+
+// SetSheetSelector 设置选择sheet的选择器, 设置了sheetName时不生效
+func (p *ExcelParser) SetSheetSelector(selectors ...SheetSelector) {
+	p.selectors = selectors
+}
+
+// sheetNames 获取需要读取的sheet opt中的选择器和解析器上的选择器同时生效
+func (p *ExcelParser) sheetNames(opt []interface{}) []string {
+	if p.sheetName != "" {
+		return []string{p.sheetName}
+	}
+	selectors := append(getSheetSelectors(opt), p.selectors...)
+	var sheetNames []string
+	for i := 1; i <= p.file.SheetCount; i++ {
+		name := p.file.GetSheetName(i)
+		sheet := SheetInfo{
+			Index:  i,
+			Name:   name,
+			Hidden: !p.file.GetSheetVisible(name),
+		}
+		if selectSheet(sheet, selectors) {
+			sheetNames = append(sheetNames, name)
+		}
+	}
+	return sheetNames
+}
+
+// tables 获取需要读取的所有表格 设置了NamedRange时只读取该区域
+func (p *ExcelParser) tables(opt []interface{}) ([]sheetTable, error) {
 	if p.file.SheetCount <= 0 {
 		return nil, errors.New("SheetCount is zero")
 	}
-	var columns [][]string
-	if p.sheetName != "" {
-		columns = p.file.GetRows(p.sheetName)
-		rows = p.rowsToResults(p.sheetName, columns, rows)
-	} else {
-		for i := 1; i <= p.file.SheetCount; i++ {
-			sheetName := p.file.GetSheetName(i)
-			sheetRows := p.file.GetRows(sheetName)
-			rows = p.rowsToResults(sheetName, sheetRows, rows)
+	name, ok := getNamedRange(opt)
+	if !ok {
+		name = p.namedRange
+	}
+	if name != "" {
+		table, err := p.rangeTable(name, opt)
+		if err != nil {
+			return nil, err
 		}
+		return []sheetTable{table}, nil
+	}
+	var tables []sheetTable
+	for _, sheetName := range p.sheetNames(opt) {
+		tables = append(tables, p.readTables(sheetName, opt)...)
+	}
+	return tables, nil
+}
+
+// ReadHeaders 读取每个sheet的表头
+func (p *ExcelParser) ReadHeaders(opt ...interface{}) ([]*Header, error) {
+	var headers []*Header
+	tables, err := p.tables(opt)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		headers = append(headers, tableHeader(table))
+	}
+	return headers, nil
+}
+
+// ReadToRows 读取并解析道行数据列表
+func (p *ExcelParser) ReadToRows(opt ...interface{}) ([]RowInterface, error) {
+	_, rows, err := p.readDocument(opt)
+	return rows, err
+}
+
+// readDocument 读取一次文档, 同时返回表头和行数据, 每一行使用所属表格的表头
+func (p *ExcelParser) readDocument(opt []interface{}) ([]*Header, []RowInterface, error) {
+	var headers []*Header
+	var rows []RowInterface
+	tables, err := p.tables(opt)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, table := range tables {
+		header := tableHeader(table)
+		headers = append(headers, header)
+		rows = p.rowsToResults(table, header, rows)
 	}
-	return rows, nil
+	return headers, rows, nil
 }
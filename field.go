@@ -2,13 +2,14 @@ package dorm
 
 import (
 	"errors"
-	"fmt"
+	"go/ast"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
 
-
 type StructField struct {
 	Name            string
 	Tag             reflect.StructTag
@@ -17,6 +18,15 @@ type StructField struct {
 	tagSettingsLock sync.RWMutex
 }
 
+// isStruct 字段是否为结构体或结构体指针
+func (sf *StructField) isStruct() bool {
+	fieldType := sf.Struct.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() == reflect.Struct
+}
+
 // TagSettingsSet
 func (sf *StructField) TagSettingsSet(key, val string) {
 	sf.tagSettingsLock.Lock()
@@ -44,6 +54,21 @@ type Field struct {
 	Field reflect.Value
 }
 
+// newField 根据结构体字段和值实例化Field, opt中的NamingStrategy用于推导没有设置name的字段
+func newField(fieldStruct reflect.StructField, value reflect.Value, opt []interface{}) *Field {
+	tagSettings := parseTagSetting(fieldStruct.Tag)
+	applyNaming(fieldStruct, tagSettings, getNamingStrategy(opt))
+	return &Field{
+		StructField: &StructField{
+			Struct:      fieldStruct,
+			Name:        fieldStruct.Name,
+			Tag:         fieldStruct.Tag,
+			TagSettings: tagSettings,
+		},
+		Field: value,
+	}
+}
+
 // Set set a value to the field
 func (field *Field) Set(value interface{}) (err error) {
 	if !field.Field.IsValid() {
@@ -74,7 +99,12 @@ func (field *Field) Set(value interface{}) (err error) {
 			if reflectValue.Type().ConvertibleTo(fieldValue.Type()) {
 				fieldValue.Set(reflectValue.Convert(fieldValue.Type()))
 			} else {
-				err = fmt.Errorf("could not convert argument of field %s from %s to %s", field.Name, reflectValue.Type(), fieldValue.Type())
+				err = &FieldError{
+					Code:   CodeConvertFailed,
+					Column: field.Name,
+					Value:  reflectValue.Interface(),
+					Type:   fieldValue.Type(),
+				}
 			}
 		}
 	} else {
@@ -102,4 +132,275 @@ func parseTagSetting(tags reflect.StructTag) map[string]string {
 		}
 	}
 	return setting
-}
\ No newline at end of file
+}
+
+const (
+	namePrefix = "NAME_"
+)
+
+// tagNames 获取标签中所有语言的名称, name在前, 其余name_xx按语言排序
+func tagNames(tagSettings map[string]string) []string {
+	var names []string
+	if name, ok := tagSettings["NAME"]; ok {
+		names = append(names, name)
+	}
+	var keys []string
+	for key := range tagSettings {
+		if strings.HasPrefix(key, namePrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		names = append(names, tagSettings[key])
+	}
+	return names
+}
+
+// HeaderLocale 写入文档时表头使用的语言, 作为opt传入DecodeDocument或WriteToExcelFile
+// 与错误信息的语言Locale分开设置, 编码时表头可以是任意语言的名称
+type HeaderLocale Locale
+
+// getHeaderLocale 未设置时返回空字符串, 使用标签中的name
+func getHeaderLocale(opt []interface{}) Locale {
+	for _, o := range opt {
+		if locale, ok := o.(HeaderLocale); ok {
+			return Locale(locale)
+		}
+	}
+	return ""
+}
+
+// localeName 获取指定语言的名称 例如 name_en name_zh_cn, 找不到时依次尝试主语言和name
+func localeName(tagSettings map[string]string, locale Locale) (string, bool) {
+	if locale != "" {
+		key := strings.ToUpper(strings.Replace(string(locale), "-", "_", -1))
+		for _, k := range []string{key, strings.Split(key, "_")[0]} {
+			for settingKey, name := range tagSettings {
+				if strings.Replace(settingKey, "-", "_", -1) == namePrefix+k {
+					return name, true
+				}
+			}
+		}
+	}
+	if name, ok := tagSettings["NAME"]; ok {
+		return name, true
+	}
+	names := tagNames(tagSettings)
+	if len(names) > 0 {
+		return names[0], true
+	}
+	return "", false
+}
+
+// encodeTagName 编码时匹配的名称, 所有语言的名称作为别名拼接在一起
+func encodeTagName(tagSettings map[string]string) (string, bool) {
+	names := tagNames(tagSettings)
+	if len(names) == 0 {
+		return "", false
+	}
+	if isReg, ok := tagSettings[regTag]; ok && isReg == "true" && len(names) > 1 {
+		var patterns []string
+		for _, name := range names {
+			patterns = append(patterns, "(?:"+name+")")
+		}
+		return strings.Join(patterns, "|"), true
+	}
+	return strings.Join(names, "|"), true
+}
+
+// encodeName 编码时匹配的名称
+func (sf *StructField) encodeName() (string, bool) {
+	sf.tagSettingsLock.RLock()
+	defer sf.tagSettingsLock.RUnlock()
+	return encodeTagName(sf.TagSettings)
+}
+
+// decodeName 写入文档时使用的名称
+func (sf *StructField) decodeName(locale Locale) (string, bool) {
+	sf.tagSettingsLock.RLock()
+	defer sf.tagSettingsLock.RUnlock()
+	return localeName(sf.TagSettings, locale)
+}
+
+// separator 基本类型切片在单元格中的分隔符, 没有设置sep时返回false
+func (sf *StructField) separator() (string, bool) {
+	sf.tagSettingsLock.RLock()
+	defer sf.tagSettingsLock.RUnlock()
+	return tagSeparator(sf.TagSettings, sf.Struct.Type)
+}
+
+func tagSeparator(tagSettings map[string]string, fieldType reflect.Type) (string, bool) {
+	sep, ok := tagSettings[sepTag]
+	if !ok || fieldType.Kind() != reflect.Slice {
+		return "", false
+	}
+	elemType := fieldType.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() == reflect.Struct {
+		return "", false
+	}
+	if sep == "" || sep == sepTag {
+		sep = ","
+	}
+	return sep, true
+}
+
+// position 通过col或colindex标签按位置绑定的列, 返回从1开始的序号
+func (sf *StructField) position() (int, bool) {
+	sf.tagSettingsLock.RLock()
+	defer sf.tagSettingsLock.RUnlock()
+	return tagPosition(sf.TagSettings)
+}
+
+// tagPosition 获取col:C或colindex:3设置的列的序号 colindex从1开始
+func tagPosition(tagSettings map[string]string) (int, bool) {
+	if letter, ok := tagSettings[colTag]; ok {
+		index := columnIndex(letter)
+		return index, index > 0
+	}
+	if value, ok := tagSettings[colIndexTag]; ok {
+		index, err := strconv.Atoi(strings.TrimSpace(value))
+		return index, err == nil && index > 0
+	}
+	return 0, false
+}
+
+// prefixes 嵌套结构体和切片在各个语言下的列名前缀
+func (sf *StructField) prefixes() []string {
+	sf.tagSettingsLock.RLock()
+	defer sf.tagSettingsLock.RUnlock()
+	return tagPrefixes(sf.TagSettings)
+}
+
+func tagPrefixes(tagSettings map[string]string) []string {
+	var prefixes []string
+	for _, name := range tagNames(tagSettings) {
+		for _, alias := range splitAliases(name) {
+			prefixes = append(prefixes, strings.Split(alias, "-")[0]+"-")
+		}
+	}
+	return prefixes
+}
+
+// structFields 获取结构体中参与映射的字段, 匿名嵌入且没有设置name的结构体会被展开
+// 展开时遵循Go的字段提升规则: 浅层的字段覆盖深层的同名字段, 同一层有多个同名字段时都不提升
+// 返回的字段的Index为从最外层开始的完整路径
+func structFields(reflectType reflect.Type) []reflect.StructField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	type candidate struct {
+		field    reflect.StructField
+		embedded bool
+	}
+	var fields []reflect.StructField
+	shadowed := map[string]bool{}
+	visited := map[reflect.Type]bool{}
+	current := []embedded{{typ: reflectType}}
+	count := map[reflect.Type]int{reflectType: 1}
+	for len(current) > 0 {
+		var next []embedded
+		nextCount := map[reflect.Type]int{}
+		var names []string
+		candidates := map[string][]candidate{}
+		add := func(c candidate, times int) {
+			name := c.field.Name
+			if _, ok := candidates[name]; !ok {
+				names = append(names, name)
+			}
+			for k := 0; k < times; k++ {
+				candidates[name] = append(candidates[name], c)
+			}
+		}
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				fieldStruct := e.typ.Field(i)
+				fieldStruct.Index = append(append([]int{}, e.index...), i)
+				if embeddedType, ok := flattenType(fieldStruct); ok {
+					add(candidate{field: fieldStruct, embedded: true}, count[e.typ])
+					nextCount[embeddedType]++
+					if nextCount[embeddedType] == 1 {
+						next = append(next, embedded{typ: embeddedType, index: fieldStruct.Index})
+					}
+					continue
+				}
+				if !ast.IsExported(fieldStruct.Name) {
+					continue
+				}
+				add(candidate{field: fieldStruct}, count[e.typ])
+			}
+		}
+		for _, name := range names {
+			if shadowed[name] {
+				continue
+			}
+			shadowed[name] = true
+			dominant := candidates[name]
+			if len(dominant) == 1 && !dominant[0].embedded {
+				fields = append(fields, dominant[0].field)
+			}
+		}
+		current, count = next, nextCount
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].Index, fields[j].Index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return fields
+}
+
+// flattenType 匿名嵌入且没有设置name的结构体或结构体指针需要展开, 返回结构体类型
+// 未导出类型的指针无法通过反射初始化, 不展开
+func flattenType(fieldStruct reflect.StructField) (reflect.Type, bool) {
+	if !fieldStruct.Anonymous {
+		return nil, false
+	}
+	fieldType := fieldStruct.Type
+	if fieldType.Kind() == reflect.Ptr {
+		if !ast.IsExported(fieldStruct.Name) {
+			return nil, false
+		}
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Struct {
+		return nil, false
+	}
+	tagSettings := parseTagSetting(fieldStruct.Tag)
+	if _, ok := tagSettings["-"]; ok {
+		return nil, false
+	}
+	if len(tagNames(tagSettings)) > 0 {
+		return nil, false
+	}
+	return fieldType, true
+}
+
+// fieldByIndex 按完整路径获取字段的值, 路径上的空指针在alloc为true时会被初始化, 否则返回false
+func fieldByIndex(value reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				if !alloc || !value.CanSet() {
+					return reflect.Value{}, false
+				}
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(x)
+	}
+	return value, true
+}
@@ -0,0 +1,164 @@
+package dorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+type embedBase struct {
+	Code string `dorm:"name:编码"`
+	Memo string
+}
+
+type embedA1 struct {
+	embedBase
+	Name string `dorm:"name:名称"`
+}
+
+type embedA2 struct {
+	embedBase
+}
+
+type embedBoth struct {
+	embedA1
+	embedA2
+}
+
+type embedShadow struct {
+	embedBase
+	Code string `dorm:"name:代码"`
+}
+
+type untaggedCode struct {
+	Code string
+}
+
+type taggedCode struct {
+	Code string `dorm:"name:编号"`
+}
+
+type embedTagged struct {
+	untaggedCode
+	taggedCode
+}
+
+type otherCode struct {
+	Code string
+}
+
+type embedUntagged struct {
+	untaggedCode
+	otherCode
+	Other untaggedCode
+}
+
+// EmbedPointer 导出类型的指针可以在编码时初始化
+type EmbedPointer struct {
+	Code string `dorm:"name:编码"`
+}
+
+type embedPtr struct {
+	*EmbedPointer
+	Price int `dorm:"name:价格"`
+}
+
+type embedUnexportedPtr struct {
+	*embedBase
+	Price int `dorm:"name:价格"`
+}
+
+type embedNamed struct {
+	embedBase `dorm:"name:基础"`
+	Price     int `dorm:"name:价格"`
+}
+
+func TestStructFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		typ    reflect.Type
+		fields map[string][]int
+	}{
+		{
+			name:   "ambiguous at the same depth",
+			typ:    reflect.TypeOf(embedBoth{}),
+			fields: map[string][]int{"Name": {0, 1}},
+		},
+		{
+			name:   "shallow field shadows",
+			typ:    reflect.TypeOf(embedShadow{}),
+			fields: map[string][]int{"Code": {1}, "Memo": {0, 1}},
+		},
+		{
+			name:   "tagged field does not break the tie",
+			typ:    reflect.TypeOf(embedTagged{}),
+			fields: map[string][]int{},
+		},
+		{
+			name:   "untagged conflict",
+			typ:    reflect.TypeOf(embedUntagged{}),
+			fields: map[string][]int{"Other": {2}},
+		},
+		{
+			name:   "pointer",
+			typ:    reflect.TypeOf(embedPtr{}),
+			fields: map[string][]int{"Code": {0, 0}, "Price": {1}},
+		},
+		{
+			name:   "unexported pointer is not flattened",
+			typ:    reflect.TypeOf(embedUnexportedPtr{}),
+			fields: map[string][]int{"Price": {1}},
+		},
+		{
+			name:   "named embedded struct is not flattened",
+			typ:    reflect.TypeOf(embedNamed{}),
+			fields: map[string][]int{"Price": {1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := map[string][]int{}
+			for _, field := range structFields(tt.typ) {
+				fields[field.Name] = field.Index
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("got %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestStructFieldsAmbiguousLikeGo(t *testing.T) {
+	if _, ok := reflect.TypeOf(embedTagged{}).FieldByName("Code"); ok {
+		t.Fatal("Go promotes Code of embedTagged, the test type is wrong")
+	}
+	typ := reflect.TypeOf(embedBoth{})
+	if _, ok := typ.FieldByName("Code"); ok {
+		t.Fatal("Go promotes Code, the test type is wrong")
+	}
+	for _, field := range structFields(typ) {
+		if field.Name == "Code" {
+			t.Errorf("Code must not be promoted, got index %v", field.Index)
+		}
+	}
+	result, _, err := DecodeDocument(&embedBoth{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result["编码"]; ok {
+		t.Errorf("got %v, 编码 must not be written", result)
+	}
+}
+
+func TestEncodeEmbeddedPointer(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "商品", rows: [][]string{
+		{"编码", "价格"},
+		{"P1", "5"},
+	}})
+	var items []embedPtr
+	if err := mapper.Encode(&items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].EmbedPointer == nil || items[0].Code != "P1" || items[0].Price != 5 {
+		t.Errorf("got %+v", items)
+	}
+}
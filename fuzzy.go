@@ -0,0 +1,67 @@
+package dorm
+
+// defaultMaxDistance 默认的最大编辑距离
+const defaultMaxDistance = 2
+
+// editDistance 计算两个字符串的编辑距离(Levenshtein)
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// allowedDistance 两个名称之间允许的编辑距离, 不超过较短名称长度的1/3
+// 避免两个字的中文列名或很短的英文列名互相匹配
+func allowedDistance(a, b string, maxDistance int) int {
+	length := minInt(len([]rune(a)), len([]rune(b)))
+	return minInt(maxDistance, length/3)
+}
+
+// closestColumn 在候选列中查找与名称编辑距离最近的列
+// unique为false表示有多个列的距离相同
+func closestColumn(names, columns []string, maxDistance int, normalization Normalization) (column string, unique bool) {
+	best := maxDistance + 1
+	for _, candidate := range columns {
+		if candidate == "" {
+			continue
+		}
+		normalized := normalization.Normalize(candidate)
+		for _, name := range names {
+			name = normalization.Normalize(name)
+			distance := editDistance(name, normalized)
+			if distance > allowedDistance(name, normalized, maxDistance) {
+				continue
+			}
+			if distance < best {
+				best = distance
+				column = candidate
+				unique = true
+			} else if distance == best && candidate != column {
+				unique = false
+			}
+		}
+	}
+	return column, unique
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
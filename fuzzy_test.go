@@ -0,0 +1,78 @@
+package dorm
+
+import (
+	"testing"
+)
+
+func TestClosestColumn(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		columns []string
+		column  string
+		unique  bool
+	}{
+		{name: "two cjk characters", names: []string{"价格"}, columns: []string{"名称", "备注"}},
+		{name: "one cjk character differs", names: []string{"单价"}, columns: []string{"售价"}},
+		{name: "long cjk name", names: []string{"商品名称"}, columns: []string{"商品名", "备注"}, column: "商品名", unique: true},
+		{name: "short ascii", names: []string{"ID"}, columns: []string{"IP", "No"}},
+		{name: "three letters", names: []string{"Age"}, columns: []string{"Ago"}, column: "Ago", unique: true},
+		{name: "typo", names: []string{"Quantity"}, columns: []string{"Quantty", "Name"}, column: "Quantty", unique: true},
+		{name: "too far", names: []string{"Quantity"}, columns: []string{"Qty"}},
+		{name: "tie", names: []string{"Colour"}, columns: []string{"Colour1", "Colour2"}, column: "Colour1"},
+		{name: "alias", names: []string{"Phone", "Mobile"}, columns: []string{"Mobil"}, column: "Mobil", unique: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column, unique := closestColumn(tt.names, tt.columns, defaultMaxDistance, nil)
+			if column != tt.column || unique != tt.unique {
+				t.Errorf("got (%q, %v), want (%q, %v)", column, unique, tt.column, tt.unique)
+			}
+		})
+	}
+}
+
+type fuzzyItem struct {
+	Name  string `dorm:"name:名称"`
+	Price string `dorm:"name:价格"`
+}
+
+func TestAutoBindShortNames(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "商品", rows: [][]string{
+		{"名称", "备注"},
+		{"苹果", "新鲜"},
+	}})
+	mapper.SetHeaderPolicy(HeaderPolicy{AutoBind: true})
+	var items []fuzzyItem
+	if err := mapper.Encode(&items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Price != "" {
+		t.Errorf("got %v, 价格 must not be bound to 备注", items)
+	}
+	if warnings := mapper.GetWarnings(); len(warnings) != 0 {
+		t.Errorf("got warnings %v, want none", warnings)
+	}
+}
+
+type requiredFuzzyItem struct {
+	Quantity int    `dorm:"name:Quantity;required"`
+	Note     string `dorm:"name:价格;required"`
+}
+
+func TestMissingColumnSuggestion(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "Stock", rows: [][]string{
+		{"Quantty", "备注"},
+		{"1", "x"},
+	}})
+	var items []requiredFuzzyItem
+	err := mapper.Encode(&items)
+	headerErr, ok := err.(*HeaderError)
+	if !ok {
+		t.Fatalf("got %v, want *HeaderError", err)
+	}
+	want := map[string]string{"Quantity": "Quantty"}
+	if len(headerErr.Suggestions) != len(want) || headerErr.Suggestions["Quantity"] != "Quantty" {
+		t.Errorf("got suggestions %v, want %v", headerErr.Suggestions, want)
+	}
+}
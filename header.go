@@ -0,0 +1,690 @@
+package dorm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	requiredTag = "REQUIRED"
+)
+
+// Header sheet的表头 同一个sheet的所有行共享一个Header, 列名的解析结果缓存在Header上
+type Header struct {
+	SheetName string
+	Columns   []string
+	// TableIndex 一个sheet拆分为多个表格时表头所属表格的序号, 从1开始, 没有拆分时为0
+	TableIndex int
+
+	lock          sync.Mutex
+	configured    bool
+	normalization Normalization
+	bindings      []headerBinding
+	resolved      map[string]resolution
+	subHeaders    map[string]*Header
+	// derived 列由行数据生成, 顺序和文档中的列位置无关
+	derived bool
+	// column 第一列在sheet中的序号, 从0开始, 读取NamedRange时不为0
+	column int
+}
+
+// headerBinding 模糊匹配时自动绑定的列
+type headerBinding struct {
+	// prefix 嵌套结构体的列名前缀
+	prefix  string
+	tagName string
+	// column 去掉前缀之后的列名
+	column string
+}
+
+// headerKey 表头所在的sheet和表格
+type headerKey struct {
+	sheetName string
+	table     int
+}
+
+// headerBindings 每个表头自动绑定的列
+type headerBindings map[headerKey][]headerBinding
+
+type resolution struct {
+	column string
+	err    error
+}
+
+// NewHeader 实例化一个表头
+func NewHeader(sheetName string, columns []string) *Header {
+	return &Header{
+		SheetName: sheetName,
+		Columns:   columns,
+	}
+}
+
+// configure 根据opt设置匹配列名时使用的规范化流水线和自动绑定的列, 只有第一次设置生效
+func (h *Header) configure(opt []interface{}) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.configured {
+		return
+	}
+	h.configured = true
+	if normalization := getNormalization(opt); normalization != nil {
+		h.normalization = normalization
+	}
+	h.bindings = append(h.bindings, getHeaderBindings(opt)[h.key()]...)
+	h.resolved = nil
+	h.subHeaders = nil
+}
+
+// resolve 查找name对应的列, name可以用|分隔多个别名, 不存在时返回空字符串
+func (h *Header) resolve(name string) (string, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.resolved == nil {
+		h.resolved = map[string]resolution{}
+	}
+	if r, ok := h.resolved[name]; ok {
+		return r.column, r.err
+	}
+	var r resolution
+	for _, binding := range h.bindings {
+		if binding.prefix == "" && binding.tagName == name {
+			r.column = binding.column
+			h.resolved[name] = r
+			return r.column, r.err
+		}
+	}
+	found := h.present(splitAliases(name))
+	switch len(found) {
+	case 0:
+	case 1:
+		r.column = found[0]
+	default:
+		r.err = ambiguousError(name, found)
+	}
+	h.resolved[name] = r
+	return r.column, r.err
+}
+
+// present 获取表头中与名称匹配的列 表头和名称都经过规范化之后再比较
+func (h *Header) present(names []string) []string {
+	var found []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		normalized := h.normalization.Normalize(name)
+		for _, column := range h.Columns {
+			if !seen[column] && h.normalization.Normalize(column) == normalized {
+				seen[column] = true
+				found = append(found, column)
+			}
+		}
+	}
+	return found
+}
+
+func (h *Header) key() headerKey {
+	return headerKey{sheetName: h.SheetName, table: h.TableIndex}
+}
+
+// sub 获取去掉前缀之后的子表头
+func (h *Header) sub(prefix string) *Header {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.subHeaders == nil {
+		h.subHeaders = map[string]*Header{}
+	}
+	if subHeader, ok := h.subHeaders[prefix]; ok {
+		return subHeader
+	}
+	subHeader := NewHeader(h.SheetName, nil)
+	subHeader.configured = true
+	subHeader.normalization = h.normalization
+	for _, binding := range h.bindings {
+		if strings.HasPrefix(binding.prefix, prefix) {
+			binding.prefix = strings.TrimPrefix(binding.prefix, prefix)
+			subHeader.bindings = append(subHeader.bindings, binding)
+		}
+	}
+	for _, column := range h.Columns {
+		if strings.HasPrefix(column, prefix) {
+			subHeader.Columns = append(subHeader.Columns, strings.TrimPrefix(column, prefix))
+		}
+	}
+	h.subHeaders[prefix] = subHeader
+	return subHeader
+}
+
+// dataHeader 没有表头信息的行使用行数据的key作为表头
+func dataHeader(data map[string]interface{}, normalization Normalization) *Header {
+	var columns []string
+	for key := range data {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+	header := NewHeader("", columns)
+	header.derived = true
+	header.configured = normalization != nil
+	header.normalization = normalization
+	return header
+}
+
+// columnLetter 获取列在文档中的列号 例如 A B AA, 列不存在或表头由行数据生成时返回空字符串
+func (h *Header) columnLetter(column string) string {
+	if h.derived {
+		return ""
+	}
+	for index, name := range h.Columns {
+		if name == column {
+			return ColumnLetter(h.column + index + 1)
+		}
+	}
+	return ""
+}
+
+// splitAliases 将 name:目标|募集目标|Target 拆分为多个别名
+func splitAliases(name string) []string {
+	return strings.Split(name, "|")
+}
+
+// primaryName 第一个别名为主名称, 写入文档时使用
+func primaryName(name string) string {
+	return splitAliases(name)[0]
+}
+
+func ambiguousError(name string, found []string) error {
+	return &FieldError{
+		Code:   CodeAmbiguousColumn,
+		Column: name,
+		Detail: strings.Join(found, ", "),
+	}
+}
+
+// HeaderPolicy 表头检查策略
+type HeaderPolicy struct {
+	// Strict 为true时表头中出现目标类型未映射的列也视为错误
+	Strict bool
+	// AutoBind 为true时找不到的列自动绑定到唯一一个相近的未映射列, 并产生警告
+	AutoBind bool
+	// MaxDistance 相近列的最大编辑距离, 0时使用默认值2, 同时不超过较短名称长度的1/3
+	MaxDistance int
+}
+
+func (policy HeaderPolicy) maxDistance() int {
+	if policy.MaxDistance > 0 {
+		return policy.MaxDistance
+	}
+	return defaultMaxDistance
+}
+
+// HeaderError 表头不符合目标类型的约定 在读取任何行之前返回
+type HeaderError struct {
+	SheetName string
+	// Missing 缺少的必填列
+	Missing []string
+	// Unknown 严格模式下目标类型未映射的列
+	Unknown []string
+	// Ambiguous 同一字段的多个别名同时出现在表头中
+	Ambiguous [][]string
+	// Suggestions 缺少的列对应的相近列
+	Suggestions map[string]string
+	Locale      Locale
+}
+
+func (e *HeaderError) Error() string {
+	return e.Message(e.Locale)
+}
+
+// Message 获取指定语言的错误信息
+func (e *HeaderError) Message(locale Locale) string {
+	var messages []string
+	for _, fieldErr := range e.FieldErrors() {
+		messages = append(messages, fieldErr.Message(locale))
+	}
+	return e.SheetName + ": " + strings.Join(messages, "; ")
+}
+
+// FieldErrors 将表头错误按列展开
+func (e *HeaderError) FieldErrors() []*FieldError {
+	var errs []*FieldError
+	for _, column := range e.Missing {
+		errs = append(errs, &FieldError{
+			Code:       CodeMissingColumn,
+			Column:     column,
+			Suggestion: e.Suggestions[column],
+			Locale:     e.Locale,
+		})
+	}
+	for _, column := range e.Unknown {
+		errs = append(errs, &FieldError{
+			Code:   CodeUnknownColumn,
+			Column: column,
+			Locale: e.Locale,
+		})
+	}
+	for _, found := range e.Ambiguous {
+		fieldErr := ambiguousError(strings.Join(found, "|"), found).(*FieldError)
+		fieldErr.Locale = e.Locale
+		errs = append(errs, fieldErr)
+	}
+	return errs
+}
+
+// columnSpec 字段映射的列
+type columnSpec struct {
+	// prefixes 嵌套结构体在各个语言下的列名前缀
+	prefixes []string
+	names    []string
+	reg      *regexp.Regexp
+	// any 为true时所有带prefix的列都视为已映射, 用于切片和自定义编码的字段
+	any      bool
+	required bool
+	// tagName 标签中的原始名称
+	tagName string
+	// extra 接收未映射列的字段, 匹配的列在严格模式下不视为未知列
+	extra bool
+	// index 通过col或colindex按位置绑定的列, 从1开始, 0表示按名称绑定
+	index int
+}
+
+func (spec columnSpec) match(column string, normalization Normalization) bool {
+	for _, prefix := range spec.prefixes {
+		if spec.matchPrefix(column, prefix, normalization) {
+			return true
+		}
+	}
+	return false
+}
+
+func (spec columnSpec) matchPrefix(column, prefix string, normalization Normalization) bool {
+	if !strings.HasPrefix(column, prefix) {
+		return false
+	}
+	if spec.any {
+		return true
+	}
+	name := strings.TrimPrefix(column, prefix)
+	if spec.reg != nil {
+		return spec.reg.MatchString(name)
+	}
+	name = normalization.Normalize(name)
+	for _, specName := range spec.names {
+		if name == normalization.Normalize(specName) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnPrefix 列匹配的前缀
+func (spec columnSpec) columnPrefix(column string) string {
+	for _, prefix := range spec.prefixes {
+		if strings.HasPrefix(column, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+func (spec columnSpec) String() string {
+	if len(spec.names) == 0 {
+		return spec.prefixes[0]
+	}
+	return spec.prefixes[0] + spec.names[0]
+}
+
+// typeColumns 获取类型通过dorm标签映射的所有列
+func typeColumns(reflectType reflect.Type, prefixes []string, strategy NamingStrategy) []columnSpec {
+	var specs []columnSpec
+	for reflectType.Kind() == reflect.Ptr {
+		reflectType = reflectType.Elem()
+	}
+	if reflectType.Kind() != reflect.Struct {
+		return specs
+	}
+	for _, fieldStruct := range structFields(reflectType) {
+		tagSettings := parseTagSetting(fieldStruct.Tag)
+		if _, ok := tagSettings["-"]; ok {
+			continue
+		}
+		if _, ok := tagSettings[metaTag]; ok {
+			continue
+		}
+		if _, ok := tagSettings[cellTag]; ok {
+			continue
+		}
+		if index, ok := tagPosition(tagSettings); ok {
+			_, required := tagSettings[requiredTag]
+			specs = append(specs, columnSpec{
+				prefixes: prefixes,
+				names:    []string{ColumnLetter(index)},
+				required: required,
+				index:    index,
+			})
+			continue
+		}
+		if _, ok := tagSettings[extraTag]; ok {
+			specs = append(specs, columnSpec{prefixes: prefixes, any: true, extra: true})
+			continue
+		}
+		applyNaming(fieldStruct, tagSettings, strategy)
+		name, ok := encodeTagName(tagSettings)
+		if !ok {
+			continue
+		}
+		_, required := tagSettings[requiredTag]
+		fieldType := fieldStruct.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		kind := fieldStruct.Type.Kind()
+		if _, ok := tagSeparator(tagSettings, fieldStruct.Type); ok {
+			kind = reflect.String
+		}
+		if _, ok := tagSettings[jsonTag]; ok {
+			kind = reflect.String
+		}
+		switch kind {
+		case reflect.Ptr, reflect.Struct:
+			subPrefixes := joinPrefixes(prefixes, tagPrefixes(tagSettings))
+			if fieldType.Kind() == reflect.Struct && !implementsEncoder(fieldType) {
+				specs = append(specs, typeColumns(fieldType, subPrefixes, strategy)...)
+			} else {
+				specs = append(specs, columnSpec{prefixes: subPrefixes, any: true, required: required})
+			}
+		case reflect.Slice:
+			subPrefixes := joinPrefixes(prefixes, tagPrefixes(tagSettings))
+			specs = append(specs, columnSpec{prefixes: subPrefixes, any: true, required: required})
+		case reflect.Map:
+			if isReg, ok := tagSettings[regTag]; ok && isReg == "true" {
+				reg, err := regexp.Compile(name)
+				if err != nil {
+					continue
+				}
+				specs = append(specs, columnSpec{prefixes: prefixes, reg: reg, required: required, tagName: name})
+				continue
+			}
+			subPrefixes := joinPrefixes(prefixes, tagPrefixes(tagSettings))
+			specs = append(specs, columnSpec{prefixes: subPrefixes, any: true, required: required})
+		default:
+			spec := columnSpec{prefixes: prefixes, names: []string{name}, required: required, tagName: name}
+			if isReg, ok := tagSettings[regTag]; ok && isReg == "true" {
+				reg, err := regexp.Compile(name)
+				if err != nil {
+					continue
+				}
+				spec.reg = reg
+			} else {
+				spec.names = splitAliases(name)
+			}
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// joinPrefixes 拼接父结构体和子结构体的前缀
+func joinPrefixes(prefixes, subPrefixes []string) []string {
+	var joined []string
+	for _, prefix := range prefixes {
+		for _, subPrefix := range subPrefixes {
+			joined = append(joined, prefix+subPrefix)
+		}
+	}
+	return joined
+}
+
+func implementsEncoder(reflectType reflect.Type) bool {
+	encoderType := reflect.TypeOf((*Encoder)(nil)).Elem()
+	return reflectType.Implements(encoderType) || reflect.PtrTo(reflectType).Implements(encoderType)
+}
+
+// checkHeader 检查表头是否满足目标类型的约定, 返回自动绑定的列和警告
+func checkHeader(header *Header, specs []columnSpec, policy HeaderPolicy,
+	normalization Normalization) (*HeaderError, []headerBinding, []error) {
+	var bindings []headerBinding
+	var warnings []error
+	headerErr := &HeaderError{SheetName: header.SheetName}
+	found := make([]bool, len(specs))
+	mapped := map[string]bool{}
+	for i, spec := range specs {
+		if spec.extra {
+			continue
+		}
+		for j, column := range header.Columns {
+			if spec.index > 0 && spec.index != header.column+j+1 {
+				continue
+			}
+			if spec.index > 0 || spec.match(column, normalization) {
+				found[i] = true
+				mapped[column] = true
+			}
+		}
+	}
+	var unmapped []string
+	for _, column := range header.Columns {
+		if column != "" && !mapped[column] {
+			unmapped = append(unmapped, column)
+		}
+	}
+	for i, spec := range specs {
+		if found[i] || spec.any || spec.reg != nil || spec.index > 0 {
+			continue
+		}
+		names := spec.fullNames()
+		candidates := spec.prefixedColumns(unmapped)
+		if policy.AutoBind {
+			column, unique := closestColumn(names, candidates, policy.maxDistance(), normalization)
+			if column != "" && unique {
+				found[i] = true
+				mapped[column] = true
+				unmapped = removeColumn(unmapped, column)
+				prefix := spec.columnPrefix(column)
+				bindings = append(bindings, headerBinding{
+					prefix:  prefix,
+					tagName: spec.tagName,
+					column:  strings.TrimPrefix(column, prefix),
+				})
+				metaInfo := MetaInfo{SheetName: header.SheetName, TableIndex: header.TableIndex}
+				warnings = append(warnings, WrapError(metaInfo, &FieldError{
+					Code:   CodeColumnAutoBound,
+					Column: spec.String(),
+					Value:  column,
+				}))
+				continue
+			}
+		}
+		if spec.required {
+			headerErr.Missing = append(headerErr.Missing, spec.String())
+			if column, _ := closestColumn(names, candidates, policy.maxDistance(), normalization); column != "" {
+				if headerErr.Suggestions == nil {
+					headerErr.Suggestions = map[string]string{}
+				}
+				headerErr.Suggestions[spec.String()] = column
+			}
+		}
+	}
+	for i, spec := range specs {
+		if spec.required && !found[i] && (spec.any || spec.reg != nil || spec.index > 0) {
+			headerErr.Missing = append(headerErr.Missing, spec.String())
+		}
+	}
+	for _, spec := range specs {
+		if len(spec.names) <= 1 {
+			continue
+		}
+		var present []string
+		for _, column := range header.Columns {
+			if spec.match(column, normalization) {
+				present = append(present, column)
+			}
+		}
+		if len(present) > 1 {
+			headerErr.Ambiguous = append(headerErr.Ambiguous, present)
+		}
+	}
+	if policy.Strict {
+		headerErr.Unknown = append(headerErr.Unknown, uncaptured(unmapped, specs)...)
+	}
+	if len(headerErr.Missing) == 0 && len(headerErr.Unknown) == 0 && len(headerErr.Ambiguous) == 0 {
+		return nil, bindings, warnings
+	}
+	return headerErr, bindings, warnings
+}
+
+// uncaptured 过滤掉能被extra字段接收的列
+func uncaptured(columns []string, specs []columnSpec) []string {
+	var filtered []string
+	for _, column := range columns {
+		captured := false
+		for _, spec := range specs {
+			if spec.extra && spec.match(column, nil) {
+				captured = true
+				break
+			}
+		}
+		if !captured {
+			filtered = append(filtered, column)
+		}
+	}
+	return filtered
+}
+
+// checkHeaders 检查解析器的所有表头是否满足specs 有多个sheet出错时返回Errors
+func checkHeaders(parser HeaderParser, specs []columnSpec, opt ...interface{}) (headerBindings, []error, error) {
+	policy := getHeaderPolicy(opt)
+	if !policy.Strict && !policy.AutoBind && !hasRequired(specs) && !hasAliases(specs) {
+		return nil, nil, nil
+	}
+	headers, err := parser.ReadHeaders(opt...)
+	if err != nil {
+		return nil, nil, err
+	}
+	locale := getLocale(opt)
+	normalization := getNormalization(opt)
+	bindings := headerBindings{}
+	var warnings []error
+	var errs Errors
+	for _, header := range headers {
+		// 空白的sheet没有数据行, 不检查表头
+		if header.empty() {
+			continue
+		}
+		headerErr, sheetBindings, sheetWarnings := checkHeader(header, specs, policy, normalization)
+		if headerErr != nil {
+			headerErr.Locale = locale
+			errs = append(errs, headerErr)
+		}
+		bindings[header.key()] = append(bindings[header.key()], sheetBindings...)
+		warnings = append(warnings, LocalizeErrors(sheetWarnings, locale)...)
+	}
+	switch len(errs) {
+	case 0:
+		return bindings, warnings, nil
+	case 1:
+		return bindings, warnings, errs[0]
+	default:
+		return bindings, warnings, errs
+	}
+}
+
+// empty 表头中没有非空的列
+func (h *Header) empty() bool {
+	for _, column := range h.Columns {
+		if column != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// fullNames 带前缀的所有别名
+func (spec columnSpec) fullNames() []string {
+	var names []string
+	for _, prefix := range spec.prefixes {
+		for _, name := range spec.names {
+			names = append(names, prefix+name)
+		}
+	}
+	return names
+}
+
+// prefixedColumns 带有当前字段前缀的列
+func (spec columnSpec) prefixedColumns(columns []string) []string {
+	var matched []string
+	for _, column := range columns {
+		for _, prefix := range spec.prefixes {
+			if strings.HasPrefix(column, prefix) {
+				matched = append(matched, column)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+func removeColumn(columns []string, column string) []string {
+	var remain []string
+	for _, c := range columns {
+		if c != column {
+			remain = append(remain, c)
+		}
+	}
+	return remain
+}
+
+func hasRequired(specs []columnSpec) bool {
+	for _, spec := range specs {
+		if spec.required {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAliases(specs []columnSpec) bool {
+	for _, spec := range specs {
+		if len(spec.names) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// unionSpecs 合并多个类型映射的列, 相同的列只保留一个, 任意一个类型必填时即为必填
+func unionSpecs(specGroups ...[]columnSpec) []columnSpec {
+	var union []columnSpec
+	index := map[string]int{}
+	for _, specs := range specGroups {
+		for _, spec := range specs {
+			key := fmt.Sprint(spec.prefixes, spec.names, spec.reg, spec.any, spec.extra, spec.index)
+			if i, ok := index[key]; ok {
+				union[i].required = union[i].required || spec.required
+				continue
+			}
+			index[key] = len(union)
+			union = append(union, spec)
+		}
+	}
+	return union
+}
+
+func getHeaderBindings(opt []interface{}) headerBindings {
+	for _, o := range opt {
+		if bindings, ok := o.(headerBindings); ok {
+			return bindings
+		}
+	}
+	return nil
+}
+
+func getHeaderPolicy(opt []interface{}) HeaderPolicy {
+	for _, o := range opt {
+		if policy, ok := o.(HeaderPolicy); ok {
+			return policy
+		}
+	}
+	return HeaderPolicy{}
+}
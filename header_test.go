@@ -0,0 +1,192 @@
+package dorm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type contractItem struct {
+	Name  string `dorm:"name:名称;required"`
+	Count int    `dorm:"name:数量"`
+}
+
+func TestHeaderContract(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  HeaderPolicy
+		header  []string
+		missing []string
+		unknown []string
+	}{
+		{name: "valid", header: []string{"名称", "数量"}},
+		{name: "optional column missing", header: []string{"名称"}},
+		{name: "required column missing", header: []string{"数量", "备注"}, missing: []string{"名称"}},
+		{name: "unknown column ignored", header: []string{"名称", "备注"}},
+		{name: "strict", policy: HeaderPolicy{Strict: true}, header: []string{"名称", "备注", "颜色"}, unknown: []string{"备注", "颜色"}},
+		{name: "strict with missing", policy: HeaderPolicy{Strict: true}, header: []string{"备注"}, missing: []string{"名称"}, unknown: []string{"备注"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, testSheet{name: "库存", rows: [][]string{tt.header, {"a", "1", "x"}}})
+			mapper.SetHeaderPolicy(tt.policy)
+			var items []contractItem
+			err := mapper.Encode(&items)
+			if tt.missing == nil && tt.unknown == nil {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			headerErr, ok := err.(*HeaderError)
+			if !ok {
+				t.Fatalf("got %v, want *HeaderError", err)
+			}
+			if headerErr.SheetName != "库存" {
+				t.Errorf("got sheet %q", headerErr.SheetName)
+			}
+			if !reflect.DeepEqual(headerErr.Missing, tt.missing) || !reflect.DeepEqual(headerErr.Unknown, tt.unknown) {
+				t.Errorf("got missing %v unknown %v, want %v %v", headerErr.Missing, headerErr.Unknown, tt.missing, tt.unknown)
+			}
+			if len(items) != 0 {
+				t.Errorf("rows must not be read after a header error, got %v", items)
+			}
+			if errs := mapper.GetErrors(); len(errs) != 1 || errs[0] != err {
+				t.Errorf("got errors %v", errs)
+			}
+		})
+	}
+}
+
+func TestHeaderErrorPerSheet(t *testing.T) {
+	mapper := openSheets(t,
+		testSheet{name: "一月", rows: [][]string{{"数量"}}},
+		testSheet{name: "二月", rows: [][]string{{"名称"}}},
+		testSheet{name: "三月", rows: [][]string{{"备注"}}},
+	)
+	var items []contractItem
+	err := mapper.Encode(&items)
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("got %v, want two header errors", err)
+	}
+	var sheets []string
+	for _, e := range errs {
+		sheets = append(sheets, e.(*HeaderError).SheetName)
+	}
+	if want := []string{"一月", "三月"}; !reflect.DeepEqual(sheets, want) {
+		t.Errorf("got sheets %v, want %v", sheets, want)
+	}
+	report := mapper.Report()
+	if len(report.Errors) != 2 || report.Errors[0].Code != CodeMissingColumn || report.Errors[0].Sheet != "一月" {
+		t.Errorf("got report errors %+v", report.Errors)
+	}
+}
+
+type aliasItem struct {
+	Target int `dorm:"name:目标|募集目标|Target"`
+}
+
+func TestHeaderAliases(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		ambiguous [][]string
+	}{
+		{name: "primary name", header: "目标"},
+		{name: "second alias", header: "募集目标"},
+		{name: "latin alias", header: "Target"},
+		{name: "two aliases present", header: "目标,Target", ambiguous: [][]string{{"目标", "Target"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := strings.Split(tt.header, ",")
+			row := make([]string, len(header))
+			for i := range row {
+				row[i] = "10"
+			}
+			mapper := openSheets(t, testSheet{name: "项目", rows: [][]string{header, row}})
+			var items []aliasItem
+			err := mapper.Encode(&items)
+			if tt.ambiguous == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(items) != 1 || items[0].Target != 10 {
+					t.Errorf("got %+v", items)
+				}
+				return
+			}
+			headerErr, ok := err.(*HeaderError)
+			if !ok {
+				t.Fatalf("got %v, want *HeaderError", err)
+			}
+			if !reflect.DeepEqual(headerErr.Ambiguous, tt.ambiguous) {
+				t.Errorf("got ambiguous %v, want %v", headerErr.Ambiguous, tt.ambiguous)
+			}
+			if fieldErr := headerErr.FieldErrors()[0]; fieldErr.Code != CodeAmbiguousColumn {
+				t.Errorf("got %+v", fieldErr)
+			}
+		})
+	}
+}
+
+func TestHeaderResolveOnce(t *testing.T) {
+	header := &Header{SheetName: "项目", Columns: []string{"募集目标", "名称"}}
+	for i := 0; i < 3; i++ {
+		column, err := header.resolve("目标|募集目标|Target")
+		if err != nil || column != "募集目标" {
+			t.Fatalf("got %q %v", column, err)
+		}
+	}
+	if len(header.resolved) != 1 {
+		t.Errorf("got %d resolutions, want one cached entry", len(header.resolved))
+	}
+}
+
+func TestHeaderSkipsEmptySheet(t *testing.T) {
+	mapper := openSheets(t,
+		testSheet{name: "库存", rows: [][]string{{"名称", "数量"}, {"苹果", "3"}}},
+		testSheet{name: "Sheet2"},
+	)
+	mapper.SetHeaderPolicy(HeaderPolicy{Strict: true})
+	var items []contractItem
+	if err := mapper.Encode(&items); err != nil {
+		t.Fatal(err)
+	}
+	if want := []contractItem{{Name: "苹果", Count: 3}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("got %v, want %v", items, want)
+	}
+}
+
+type aliasAddress struct {
+	City string `dorm:"name:城市"`
+}
+
+type aliasCustomer struct {
+	Name    string            `dorm:"name:名称"`
+	Address aliasAddress      `dorm:"name:地址-|Addr-"`
+	Phones  map[string]string `dorm:"name:电话-|Phone-"`
+}
+
+func TestNestedAliases(t *testing.T) {
+	for _, header := range [][]string{{"名称", "地址-城市", "电话-手机"}, {"名称", "Addr-城市", "Phone-手机"}} {
+		mapper := openSheets(t, testSheet{name: "客户", rows: [][]string{header, {"张三", "杭州", "138"}}})
+		var items []aliasCustomer
+		if err := mapper.Encode(&items); err != nil {
+			t.Fatal(err)
+		}
+		want := aliasCustomer{Name: "张三", Address: aliasAddress{City: "杭州"}, Phones: map[string]string{"手机": "138"}}
+		if len(items) != 1 || !reflect.DeepEqual(items[0], want) {
+			t.Errorf("%v: got %+v, want %+v", header, items, want)
+		}
+	}
+	result, _, err := DecodeDocument(&aliasCustomer{Name: "张三", Address: aliasAddress{City: "杭州"}, Phones: map[string]string{"手机": "138"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"名称": "张三", "地址-城市": "杭州", "电话-手机": "138"}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("got %v, want %v", result, want)
+	}
+}
@@ -0,0 +1,105 @@
+package dorm
+
+import (
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+)
+
+// Layout sheet中数据的布局
+type Layout int
+
+const (
+	// LayoutHorizontal 第一行为表头, 之后每一行为一条记录
+	LayoutHorizontal Layout = iota
+	// LayoutVertical 第一列为字段名, 之后每一列为一条记录, 适用于配置类的sheet
+	LayoutVertical
+	// LayoutForm 每个sheet为一条记录, 没有表头也不读取行数据, 字段通过cell标签从固定的单元格读取, 不支持TableSplit
+	LayoutForm
+	// LayoutHeaderless 没有表头, 每一行为一条记录, 行数据以列号 A B C 为key, 字段通过col或colindex标签绑定
+	// 行号的约定与其他布局相同, 见MetaInfo.RowIndex
+	LayoutHeaderless
+)
+
+// arrange 将按行读取的表格转换为第一行为表头的形式, 同时转换每一行在sheet中的序号
+func (layout Layout) arrange(table sheetTable) sheetTable {
+	switch layout {
+	case LayoutVertical:
+		table.rows, table.lines = transpose(table.rows, table.column)
+	case LayoutForm:
+		table.rows, table.lines, table.form = nil, nil, true
+	case LayoutHeaderless:
+		table.rows = append([][]string{letterHeader(table.rows, table.column)}, table.rows...)
+		table.lines = append([]int{-1}, table.lines...)
+	}
+	return table
+}
+
+// transpose 行列互换 全部为空的列被忽略, 同时返回每一列在sheet中的序号
+func transpose(rows [][]string, column int) ([][]string, []int) {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	var columns [][]string
+	var lines []int
+	for j := 0; j < width; j++ {
+		cells := make([]string, len(rows))
+		empty := true
+		for i, row := range rows {
+			if j < len(row) {
+				cells[i] = row[j]
+			}
+			if cells[i] != "" {
+				empty = false
+			}
+		}
+		if empty && j > 0 {
+			continue
+		}
+		columns = append(columns, cells)
+		lines = append(lines, column+j)
+	}
+	return columns, lines
+}
+
+// letterHeader 以列号作为表头 column为第一列在sheet中的序号
+func letterHeader(rows [][]string, column int) []string {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	header := make([]string, width)
+	for i := range header {
+		header[i] = ColumnLetter(column + i + 1)
+	}
+	return header
+}
+
+// ColumnLetter 获取列号 index从1开始, 例如 1为A 27为AA
+// 没有表头的自定义解析器可以用它作为行数据的key
+func ColumnLetter(index int) string {
+	return excelize.ToAlphaString(index - 1)
+}
+
+// columnIndex 获取列号对应的序号, 从1开始, 列号不合法时返回0
+func columnIndex(letter string) int {
+	letter = strings.ToUpper(strings.TrimSpace(letter))
+	if letter == "" || strings.Trim(letter, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return 0
+	}
+	return excelize.TitleToNumber(letter) + 1
+}
+
+func getLayout(opt []interface{}) (Layout, bool) {
+	for _, o := range opt {
+		if layout, ok := o.(Layout); ok {
+			return layout, true
+		}
+	}
+	return LayoutHorizontal, false
+}
@@ -0,0 +1,158 @@
+package dorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+type layoutConfig struct {
+	Name    string `dorm:"name:名称"`
+	Version int    `dorm:"name:版本"`
+	Row     int    `dorm:"meta:row"`
+}
+
+func TestVerticalSingleStruct(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "配置", rows: [][]string{
+		{"名称", "导入工具"},
+		{"版本", "3"},
+	}})
+	provenance := NewProvenance()
+	checker := NewRefChecker()
+	cfg := layoutConfig{Name: "默认"}
+	if err := mapper.Encode(&cfg, LayoutVertical, provenance, checker); err != nil {
+		t.Fatal(err)
+	}
+	if want := (layoutConfig{Name: "导入工具", Version: 3, Row: 2}); cfg != want {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+	fields, ok := provenance.Get(&cfg)
+	if !ok {
+		t.Fatal("provenance of the caller's struct not found")
+	}
+	if source := fields["Version"]; source.Value != "3" || source.Header != "版本" {
+		t.Errorf("got source %+v", source)
+	}
+	if len(checker.records) != 1 || checker.records[0].value != &cfg {
+		t.Errorf("ref checker must record the caller's struct, got %+v", checker.records)
+	}
+	if report := mapper.Report(); report.Summary.TotalRows != 1 || report.Summary.OkRows != 1 {
+		t.Errorf("got summary %+v", report.Summary)
+	}
+}
+
+func TestSingleStructManyRecords(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "配置", rows: [][]string{
+		{"名称", "版本"},
+		{"a", "1"},
+		{"b", "2"},
+	}})
+	cfg := layoutConfig{Name: "默认"}
+	if err := mapper.Encode(&cfg); err == nil {
+		t.Fatal("expected an error for two records")
+	}
+	if cfg.Name != "默认" {
+		t.Errorf("struct must be left unchanged, got %+v", cfg)
+	}
+}
+
+func TestSingleStructRowError(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "配置", rows: [][]string{
+		{"名称", "a"},
+		{"版本", "x"},
+	}})
+	for _, policy := range []ErrorPolicy{CollectAll, AllOrNothing} {
+		cfg := layoutConfig{Name: "默认"}
+		err := mapper.Encode(&cfg, LayoutVertical, policy)
+		if errs, ok := err.(Errors); !ok || len(errs) != 1 {
+			t.Fatalf("got %v, want one row error", err)
+		}
+		if want := (layoutConfig{Name: "默认"}); cfg != want {
+			t.Errorf("got %+v, want the struct untouched", cfg)
+		}
+	}
+}
+
+func TestLayoutRowIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout Layout
+		rows   [][]string
+		want   []int
+	}{
+		{
+			name:   "horizontal",
+			layout: LayoutHorizontal,
+			rows:   [][]string{{"名称", "版本"}, {"a", "1"}, {"b", "2"}},
+			want:   []int{2, 3},
+		},
+		{
+			name:   "vertical skips empty columns",
+			layout: LayoutVertical,
+			rows:   [][]string{{"名称", "a", "", "b"}, {"版本", "1", "", "2"}},
+			want:   []int{2, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, testSheet{name: "配置", rows: tt.rows})
+			var items []layoutConfig
+			if err := mapper.Encode(&items, tt.layout); err != nil {
+				t.Fatal(err)
+			}
+			var rows []int
+			for _, item := range items {
+				rows = append(rows, item.Row)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("got rows %v, want %v", rows, tt.want)
+			}
+		})
+	}
+}
+
+type positionAddress struct {
+	Province string `dorm:"name:省"`
+	City     string `dorm:"col:C"`
+}
+
+type positionItem struct {
+	Name string          `dorm:"col:A"`
+	Addr positionAddress `dorm:"name:地址"`
+	Row  int             `dorm:"meta:row"`
+}
+
+func TestPositionInNestedStruct(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "客户", rows: [][]string{
+		{"名称", "地址-省", "地址-市"},
+		{"张三", "浙江", "杭州"},
+	}})
+	var items []positionItem
+	if err := mapper.Encode(&items); err != nil {
+		t.Fatal(err)
+	}
+	want := []positionItem{{Name: "张三", Addr: positionAddress{Province: "浙江", City: "杭州"}, Row: 2}}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+}
+
+type headerlessItem struct {
+	Name  string `dorm:"col:A"`
+	Count int    `dorm:"colindex:3"`
+	Row   int    `dorm:"meta:row"`
+}
+
+func TestHeaderlessRowIndex(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "数据", rows: [][]string{
+		{"a", "x", "1"},
+		{"b", "y", "2"},
+	}})
+	var items []headerlessItem
+	if err := mapper.Encode(&items, LayoutHeaderless); err != nil {
+		t.Fatal(err)
+	}
+	want := []headerlessItem{{"a", 1, 1}, {"b", 2, 2}}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+}
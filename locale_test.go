@@ -0,0 +1,100 @@
+package dorm
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+)
+
+type localeItem struct {
+	Target int    `dorm:"name:目标;name_en:Target;name_ru:Цель"`
+	Note   string `dorm:"name:备注;name_en:Note"`
+}
+
+func TestLocaleName(t *testing.T) {
+	settings := map[string]string{"NAME": "目标", "NAME_EN": "Target", "NAME_ZH_TW": "目標"}
+	tests := []struct {
+		locale Locale
+		want   string
+	}{
+		{"", "目标"},
+		{"en", "Target"},
+		{"en-US", "Target"},
+		{"zh-TW", "目標"},
+		{"ru", "目标"},
+	}
+	for _, tt := range tests {
+		if got, _ := localeName(settings, tt.locale); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeAnyLocale(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+	}{
+		{name: "chinese", header: []string{"目标", "备注"}},
+		{name: "english", header: []string{"Target", "Note"}},
+		{name: "russian falls back to the default name", header: []string{"Цель", "备注"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, testSheet{name: "项目", rows: [][]string{tt.header, {"10", "a"}}})
+			var items []localeItem
+			if err := mapper.Encode(&items); err != nil {
+				t.Fatal(err)
+			}
+			if want := []localeItem{{Target: 10, Note: "a"}}; !reflect.DeepEqual(items, want) {
+				t.Errorf("got %+v, want %+v", items, want)
+			}
+		})
+	}
+}
+
+func TestDecodeLocale(t *testing.T) {
+	item := &localeItem{Target: 10, Note: "a"}
+	tests := []struct {
+		locale interface{}
+		keys   []string
+	}{
+		{HeaderLocale(""), []string{"目标", "备注"}},
+		{HeaderLocale("en"), []string{"Target", "Note"}},
+		{HeaderLocale("ru"), []string{"Цель", "备注"}},
+		{LocaleEn, []string{"目标", "备注"}},
+	}
+	for _, tt := range tests {
+		result, keys, err := DecodeDocument(item, tt.locale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(keys, tt.keys) || result[tt.keys[0]] != 10 {
+			t.Errorf("%#v: got %v %v, want keys %v", tt.locale, keys, result, tt.keys)
+		}
+	}
+}
+
+func TestWriteLocaleRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteToExcelFile(&buffer, "Sheet1", []*localeItem{{Target: 10, Note: "a"}}, HeaderLocale("en")); err != nil {
+		t.Fatal(err)
+	}
+	file, err := excelize.OpenReader(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header, want := file.GetRows("Sheet1")[0], []string{"Target", "Note"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("got header %v, want %v", header, want)
+	}
+	mapper := openWorkbook(t, file)
+	var items []localeItem
+	if err := mapper.Encode(&items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Target != 10 {
+		t.Errorf("got %+v", items)
+	}
+}
@@ -0,0 +1,256 @@
+package dorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Locale 语言代码 例如 zh-CN en
+type Locale string
+
+const (
+	LocaleZhCN Locale = "zh-CN"
+	LocaleEn   Locale = "en"
+)
+
+// ErrorCode 稳定的错误码, 不随语言变化
+type ErrorCode string
+
+const (
+	CodeConvertFailed     ErrorCode = "convert_failed"
+	CodeInvalidValue      ErrorCode = "invalid_value"
+	CodeInvalidRegexp     ErrorCode = "invalid_regexp"
+	CodeInvalidTag        ErrorCode = "invalid_tag"
+	CodeDanglingReference ErrorCode = "dangling_reference"
+	CodeMissingColumn     ErrorCode = "missing_column"
+	CodeUnknownColumn     ErrorCode = "unknown_column"
+	CodeAmbiguousColumn   ErrorCode = "ambiguous_column"
+	CodeColumnAutoBound   ErrorCode = "column_auto_bound"
+	CodeInvalidJSON       ErrorCode = "invalid_json"
+	CodeUnknownType       ErrorCode = "unknown_type"
+	// CodeDidYouMean 提示片段, 有相近的列时追加在错误信息之后
+	CodeDidYouMean ErrorCode = "did_you_mean"
+)
+
+// 模板中可用的占位符: {column} 列名 {value} 单元格的值 {type} 目标类型 {detail} 附加信息 {suggestion} 相近的列
+var (
+	messagesLock sync.RWMutex
+	messages     = map[Locale]map[ErrorCode]string{
+		LocaleEn: {
+			CodeConvertFailed:     `column "{column}": cannot convert value "{value}" to {type}`,
+			CodeInvalidValue:      `column "{column}": invalid value "{value}"`,
+			CodeInvalidRegexp:     `column "{column}": invalid regular expression`,
+			CodeInvalidTag:        `column "{column}": invalid tag setting {detail}`,
+			CodeDanglingReference: `column "{column}": value "{value}" not found in {detail}`,
+			CodeMissingColumn:     `missing required column "{column}"`,
+			CodeUnknownColumn:     `unknown column "{column}"`,
+			CodeAmbiguousColumn:   `columns {detail} are aliases of the same field, keep only one of them`,
+			CodeColumnAutoBound:   `column "{column}" not found, using similar column "{value}"`,
+			CodeInvalidJSON:       `column "{column}": invalid JSON: {detail}`,
+			CodeUnknownType:       `column "{column}": no type registered for value "{value}"`,
+			CodeDidYouMean:        `, did you mean "{suggestion}"?`,
+		},
+		LocaleZhCN: {
+			CodeConvertFailed:     `列「{column}」的值「{value}」无法转换为{type}`,
+			CodeInvalidValue:      `列「{column}」的值「{value}」不合法`,
+			CodeInvalidRegexp:     `列「{column}」的正则表达式不合法`,
+			CodeInvalidTag:        `列「{column}」的标签配置{detail}不合法`,
+			CodeDanglingReference: `列「{column}」的值「{value}」在{detail}中不存在`,
+			CodeMissingColumn:     `缺少必填列「{column}」`,
+			CodeUnknownColumn:     `无法识别的列「{column}」`,
+			CodeAmbiguousColumn:   `列{detail}是同一字段的别名, 只能保留其中一列`,
+			CodeColumnAutoBound:   `未找到列「{column}」, 已使用相近的列「{value}」`,
+			CodeInvalidJSON:       `列「{column}」的JSON格式不合法: {detail}`,
+			CodeUnknownType:       `列「{column}」的值「{value}」没有对应的类型`,
+			CodeDidYouMean:        `, 是否为「{suggestion}」?`,
+		},
+	}
+	typeNames = map[Locale]map[reflect.Kind]string{
+		LocaleEn: {
+			reflect.String:  "text",
+			reflect.Bool:    "boolean",
+			reflect.Int:     "integer",
+			reflect.Int8:    "integer",
+			reflect.Int16:   "integer",
+			reflect.Int32:   "integer",
+			reflect.Int64:   "integer",
+			reflect.Uint:    "integer",
+			reflect.Uint8:   "integer",
+			reflect.Uint16:  "integer",
+			reflect.Uint32:  "integer",
+			reflect.Uint64:  "integer",
+			reflect.Float32: "number",
+			reflect.Float64: "number",
+		},
+		LocaleZhCN: {
+			reflect.String:  "文本",
+			reflect.Bool:    "布尔值",
+			reflect.Int:     "整数",
+			reflect.Int8:    "整数",
+			reflect.Int16:   "整数",
+			reflect.Int32:   "整数",
+			reflect.Int64:   "整数",
+			reflect.Uint:    "整数",
+			reflect.Uint8:   "整数",
+			reflect.Uint16:  "整数",
+			reflect.Uint32:  "整数",
+			reflect.Uint64:  "整数",
+			reflect.Float32: "数字",
+			reflect.Float64: "数字",
+		},
+	}
+)
+
+// RegisterMessages 注册或覆盖某个语言的错误信息模板
+func RegisterMessages(locale Locale, templates map[ErrorCode]string) {
+	messagesLock.Lock()
+	defer messagesLock.Unlock()
+	if _, ok := messages[locale]; !ok {
+		messages[locale] = map[ErrorCode]string{}
+	}
+	for code, template := range templates {
+		messages[locale][code] = template
+	}
+}
+
+// FieldError 字段编码错误 Column为表头中的列名
+type FieldError struct {
+	Code   ErrorCode
+	Column string
+	Value  interface{}
+	Type   reflect.Type
+	Detail string
+	// Suggestion 表头中相近的列
+	Suggestion string
+	Locale     Locale
+}
+
+func (e *FieldError) Error() string {
+	return e.Message(e.Locale)
+}
+
+// Message 获取指定语言的错误信息
+func (e *FieldError) Message(locale Locale) string {
+	template, ok := lookupMessage(locale, e.Code)
+	if !ok {
+		return fmt.Sprintf("%s: %s %v %s", e.Code, e.Column, e.Value, e.Detail)
+	}
+	var value string
+	if e.Value != nil {
+		value = fmt.Sprint(e.Value)
+	}
+	if e.Suggestion != "" {
+		if didYouMean, ok := lookupMessage(locale, CodeDidYouMean); ok {
+			template += didYouMean
+		}
+	}
+	replacer := strings.NewReplacer(
+		"{column}", e.Column,
+		"{value}", value,
+		"{type}", e.typeName(locale),
+		"{detail}", e.Detail,
+		"{suggestion}", e.Suggestion,
+	)
+	return replacer.Replace(template)
+}
+
+func (e *FieldError) typeName(locale Locale) string {
+	if e.Type == nil {
+		return ""
+	}
+	messagesLock.RLock()
+	defer messagesLock.RUnlock()
+	for _, l := range localeFallbacks(locale) {
+		if name, ok := typeNames[l][e.Type.Kind()]; ok {
+			return name
+		}
+	}
+	return e.Type.String()
+}
+
+func lookupMessage(locale Locale, code ErrorCode) (string, bool) {
+	messagesLock.RLock()
+	defer messagesLock.RUnlock()
+	for _, l := range localeFallbacks(locale) {
+		if template, ok := messages[l][code]; ok {
+			return template, true
+		}
+	}
+	return "", false
+}
+
+// localeFallbacks 语言的查找顺序 例如 en-US -> en-US en
+func localeFallbacks(locale Locale) []Locale {
+	var locales []Locale
+	if locale != "" {
+		locales = append(locales, locale)
+		if index := strings.Index(string(locale), "-"); index > 0 {
+			locales = append(locales, locale[:index])
+		}
+	}
+	return append(locales, LocaleEn)
+}
+
+// LocalizeErrors 将错误转换为指定语言
+func LocalizeErrors(errs []error, locale Locale) []error {
+	var localized []error
+	for _, err := range errs {
+		localized = append(localized, localizeError(err, locale))
+	}
+	return localized
+}
+
+func localizeError(err error, locale Locale) error {
+	switch e := err.(type) {
+	case *FieldError:
+		fieldErr := *e
+		fieldErr.Locale = locale
+		return &fieldErr
+	case *RowError:
+		if e.Err == nil {
+			return e
+		}
+		return WrapError(e.MetaInfo, localizeError(e.Err, locale))
+	case *HeaderError:
+		headerErr := *e
+		headerErr.Locale = locale
+		return &headerErr
+	}
+	return err
+}
+
+// withColumn 为错误补充表头中的列名和单元格的值
+func withColumn(err error, column string, value interface{}) error {
+	if fieldErr, ok := err.(*FieldError); ok {
+		fieldErr.Column = column
+		if fieldErr.Value == nil {
+			fieldErr.Value = value
+		}
+		return fieldErr
+	}
+	return &FieldError{
+		Code:   CodeInvalidValue,
+		Column: column,
+		Value:  value,
+		Detail: err.Error(),
+	}
+}
+
+// withPrefix 为子对象的错误补充列名前缀
+func withPrefix(err error, prefix string) error {
+	if fieldErr, ok := err.(*FieldError); ok {
+		fieldErr.Column = prefix + fieldErr.Column
+	}
+	return err
+}
+
+func getLocale(opt []interface{}) Locale {
+	for _, o := range opt {
+		if locale, ok := o.(Locale); ok {
+			return locale
+		}
+	}
+	return LocaleEn
+}
@@ -0,0 +1,92 @@
+package dorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldErrorMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *FieldError
+		locale Locale
+		want   string
+	}{
+		{
+			name:   "english",
+			err:    &FieldError{Code: CodeConvertFailed, Column: "数量", Value: "x", Type: reflect.TypeOf(0)},
+			locale: LocaleEn,
+			want:   `column "数量": cannot convert value "x" to integer`,
+		},
+		{
+			name:   "chinese",
+			err:    &FieldError{Code: CodeConvertFailed, Column: "数量", Value: "x", Type: reflect.TypeOf(0)},
+			locale: LocaleZhCN,
+			want:   `列「数量」的值「x」无法转换为整数`,
+		},
+		{
+			name:   "region falls back to language",
+			err:    &FieldError{Code: CodeUnknownColumn, Column: "备注"},
+			locale: "en-US",
+			want:   `unknown column "备注"`,
+		},
+		{
+			name:   "unknown locale falls back to english",
+			err:    &FieldError{Code: CodeUnknownColumn, Column: "备注"},
+			locale: "fr",
+			want:   `unknown column "备注"`,
+		},
+		{
+			name:   "suggestion",
+			err:    &FieldError{Code: CodeMissingColumn, Column: "商品名称", Suggestion: "商品名"},
+			locale: LocaleZhCN,
+			want:   `缺少必填列「商品名称」, 是否为「商品名」?`,
+		},
+		{
+			name:   "unregistered code",
+			err:    &FieldError{Code: "custom", Column: "a", Value: 1, Detail: "d"},
+			locale: LocaleEn,
+			want:   `custom: a 1 d`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Message(tt.locale); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterMessages(t *testing.T) {
+	RegisterMessages("ja", map[ErrorCode]string{
+		CodeUnknownColumn: `不明な列「{column}」`,
+	})
+	err := &FieldError{Code: CodeUnknownColumn, Column: "備考"}
+	if got, want := err.Message("ja"), `不明な列「備考」`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	err = &FieldError{Code: CodeMissingColumn, Column: "備考"}
+	if got, want := err.Message("ja"), `missing required column "備考"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeLocalizedErrors(t *testing.T) {
+	mapper := openSheets(t, policySheet())
+	mapper.SetLocale(LocaleZhCN)
+	var items []*reportItem
+	if err := mapper.Encode(&items, FailFast); err == nil {
+		t.Fatal("expected a row error")
+	}
+	errs := mapper.GetErrors()
+	if len(errs) != 1 {
+		t.Fatalf("got %v, want one error", errs)
+	}
+	if got, want := errs[0].Error(), `{库存 2}, error:列「数量」的值「x」无法转换为整数`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if report := mapper.Report(); report.Errors[0].Message != `列「数量」的值「x」无法转换为整数` {
+		t.Errorf("got report message %q", report.Errors[0].Message)
+	}
+}
@@ -0,0 +1,78 @@
+package dorm
+
+import (
+	"reflect"
+	"strings"
+)
+
+// NamingStrategy 字段没有在dorm标签中设置name时推导列名的策略, 无法推导时返回false
+type NamingStrategy func(field reflect.StructField) (string, bool)
+
+// JSONNaming 使用json标签中的名称
+func JSONNaming(field reflect.StructField) (string, bool) {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return "", false
+	}
+	return name, true
+}
+
+// GormCommentNaming 使用gorm标签中comment的内容 例如 gorm:"comment:'产品名称'"
+func GormCommentNaming(field reflect.StructField) (string, bool) {
+	for _, setting := range strings.Split(field.Tag.Get("gorm"), ";") {
+		kv := strings.SplitN(setting, ":", 2)
+		if len(kv) < 2 || strings.ToUpper(strings.TrimSpace(kv[0])) != "COMMENT" {
+			continue
+		}
+		comment := strings.Trim(strings.TrimSpace(kv[1]), "'\"")
+		if comment != "" {
+			return comment, true
+		}
+	}
+	return "", false
+}
+
+// FieldNaming 使用Go的字段名
+func FieldNaming(field reflect.StructField) (string, bool) {
+	return field.Name, true
+}
+
+// NamingChain 依次尝试多个策略, 使用第一个推导出的名称
+func NamingChain(strategies ...NamingStrategy) NamingStrategy {
+	return func(field reflect.StructField) (string, bool) {
+		for _, strategy := range strategies {
+			if name, ok := strategy(field); ok {
+				return name, true
+			}
+		}
+		return "", false
+	}
+}
+
+// applyNaming 没有设置name的字段使用策略推导出的名称
+func applyNaming(fieldStruct reflect.StructField, tagSettings map[string]string, strategy NamingStrategy) {
+	if strategy == nil || fieldStruct.Anonymous {
+		return
+	}
+	if _, ok := tagSettings["-"]; ok {
+		return
+	}
+	if len(tagNames(tagSettings)) > 0 {
+		return
+	}
+	if name, ok := strategy(fieldStruct); ok {
+		tagSettings["NAME"] = name
+	}
+}
+
+func getNamingStrategy(opt []interface{}) NamingStrategy {
+	for _, o := range opt {
+		switch strategy := o.(type) {
+		case NamingStrategy:
+			return strategy
+		case func(reflect.StructField) (string, bool):
+			return strategy
+		}
+	}
+	return nil
+}
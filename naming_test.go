@@ -0,0 +1,81 @@
+package dorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+type namingItem struct {
+	ID      int    `json:"id" gorm:"primary_key;comment:'编号'"`
+	Title   string `json:"-" gorm:"comment:\"标题\""`
+	Price   int    `json:"price,omitempty"`
+	Stock   int    `json:"stock" dorm:"name:库存"`
+	Ignored string `json:"ignored" dorm:"-"`
+}
+
+func TestNamingStrategies(t *testing.T) {
+	itemType := reflect.TypeOf(namingItem{})
+	tests := []struct {
+		name     string
+		strategy NamingStrategy
+		want     map[string]string
+	}{
+		{"json", JSONNaming, map[string]string{"ID": "id", "Price": "price", "Stock": "stock", "Ignored": "ignored"}},
+		{"gorm comment", GormCommentNaming, map[string]string{"ID": "编号", "Title": "标题"}},
+		{"field name", FieldNaming, map[string]string{"ID": "ID", "Title": "Title", "Price": "Price", "Stock": "Stock", "Ignored": "Ignored"}},
+		{"chain", NamingChain(GormCommentNaming, JSONNaming), map[string]string{"ID": "编号", "Title": "标题", "Price": "price", "Stock": "stock", "Ignored": "ignored"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			for i := 0; i < itemType.NumField(); i++ {
+				field := itemType.Field(i)
+				if name, ok := tt.strategy(field); ok {
+					got[field.Name] = name
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeWithNamingStrategy(t *testing.T) {
+	sheet := testSheet{name: "商品", rows: [][]string{
+		{"编号", "标题", "price", "库存", "ignored"},
+		{"1", "苹果", "5", "7", "x"},
+	}}
+	tests := []struct {
+		name     string
+		strategy NamingStrategy
+		want     namingItem
+	}{
+		{name: "without strategy", want: namingItem{Stock: 7}},
+		{name: "chain", strategy: NamingChain(GormCommentNaming, JSONNaming), want: namingItem{ID: 1, Title: "苹果", Price: 5, Stock: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, sheet)
+			mapper.SetNamingStrategy(tt.strategy)
+			var items []namingItem
+			if err := mapper.Encode(&items); err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 1 || items[0] != tt.want {
+				t.Errorf("got %+v, want %+v", items, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeWithNamingStrategy(t *testing.T) {
+	item := &namingItem{ID: 1, Title: "苹果", Price: 5, Stock: 7}
+	_, keys, err := DecodeDocument(item, NamingStrategy(JSONNaming))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"id", "price", "库存"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got keys %v, want %v", keys, want)
+	}
+}
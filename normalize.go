@@ -0,0 +1,93 @@
+package dorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalizer 表头名称的规范化步骤
+type Normalizer func(string) string
+
+// Normalization 表头规范化流水线, 按顺序执行
+// 匹配时表头和标签中的名称都会经过同一个流水线
+type Normalization []Normalizer
+
+// DefaultNormalization 默认的规范化流水线
+var DefaultNormalization = Normalization{
+	FullWidthToHalfWidth,
+	CollapseSpace,
+	StripMarkers,
+	StripUnit,
+	FoldCase,
+}
+
+// Normalize 执行规范化
+func (n Normalization) Normalize(name string) string {
+	for _, normalizer := range n {
+		name = normalizer(name)
+	}
+	return name
+}
+
+// FullWidthToHalfWidth 全角字符转为半角
+func FullWidthToHalfWidth(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '　' {
+			return ' '
+		}
+		if r >= '！' && r <= '～' {
+			return r - 0xfee0
+		}
+		return r
+	}, name)
+}
+
+// CollapseSpace 去掉首尾空白, 中间连续的空白(包括换行)合并为一个空格
+func CollapseSpace(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// RemoveSpace 去掉所有空白, 适用于被换行折断的中文表头
+func RemoveSpace(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, name)
+}
+
+// StripMarkers 去掉必填标记*和末尾的冒号
+func StripMarkers(name string) string {
+	name = strings.Trim(name, "*＊ ")
+	name = strings.TrimRight(name, ":： ")
+	return strings.Trim(name, "*＊ ")
+}
+
+// StripUnit 去掉末尾括号中的单位 例如 价格(元)
+func StripUnit(name string) string {
+	for _, pair := range [][2]string{{"(", ")"}, {"（", "）"}} {
+		trimmed := strings.TrimSpace(name)
+		if !strings.HasSuffix(trimmed, pair[1]) {
+			continue
+		}
+		if index := strings.LastIndex(trimmed, pair[0]); index > 0 {
+			return strings.TrimSpace(trimmed[:index])
+		}
+	}
+	return name
+}
+
+// FoldCase 统一为小写
+func FoldCase(name string) string {
+	return strings.ToLower(name)
+}
+
+func getNormalization(opt []interface{}) Normalization {
+	for _, o := range opt {
+		if normalization, ok := o.(Normalization); ok {
+			return normalization
+		}
+	}
+	return nil
+}
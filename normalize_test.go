@@ -0,0 +1,71 @@
+package dorm
+
+import (
+	"testing"
+)
+
+func TestNormalizers(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalizer Normalizer
+		in, want   string
+	}{
+		{"full width letters", FullWidthToHalfWidth, "ＰＲＩＣＥ（元）", "PRICE(元)"},
+		{"full width space", FullWidthToHalfWidth, "单　价", "单 价"},
+		{"collapse space", CollapseSpace, " 单\n价  金额 ", "单 价 金额"},
+		{"remove space", RemoveSpace, " 单\n价 ", "单价"},
+		{"leading marker", StripMarkers, "*名称", "名称"},
+		{"trailing marker and colon", StripMarkers, "名称＊：", "名称"},
+		{"unit", StripUnit, "单价(元)", "单价"},
+		{"full width unit", StripUnit, "单价（元） ", "单价"},
+		{"only brackets kept", StripUnit, "(元)", "(元)"},
+		{"fold case", FoldCase, "Unit Price", "unit price"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.normalizer(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultNormalization(t *testing.T) {
+	for _, name := range []string{"* Unit　Price（元）：", "unit price", "UNIT\nPRICE (元)"} {
+		if got := DefaultNormalization.Normalize(name); got != "unit price" {
+			t.Errorf("%q: got %q", name, got)
+		}
+	}
+	if got := Normalization(nil).Normalize(" 名称 "); got != " 名称 " {
+		t.Errorf("an empty pipeline must keep the name, got %q", got)
+	}
+}
+
+func TestEncodeNormalizedHeader(t *testing.T) {
+	sheet := testSheet{name: "库存", rows: [][]string{
+		{"*名称：", "数量\n(件)"},
+		{"苹果", "3"},
+	}}
+	tests := []struct {
+		name          string
+		normalization Normalization
+		want          reportItem
+	}{
+		{name: "without normalization", want: reportItem{}},
+		{name: "default", normalization: DefaultNormalization, want: reportItem{Name: "苹果", Count: 3}},
+		{name: "custom", normalization: Normalization{RemoveSpace, StripUnit}, want: reportItem{Count: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, sheet)
+			mapper.SetNormalization(tt.normalization)
+			var items []reportItem
+			if err := mapper.Encode(&items); err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != 1 || items[0] != tt.want {
+				t.Errorf("got %+v, want %+v", items, tt.want)
+			}
+		})
+	}
+}
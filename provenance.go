@@ -0,0 +1,107 @@
+package dorm
+
+import (
+	"strconv"
+)
+
+// CellSource 字段的值来源的单元格
+type CellSource struct {
+	Sheet string
+	// Row 同MetaInfo.RowIndex, 从0开始
+	Row string
+	// Column 列号 例如 A B AA, 表头不是从文档中读取时为空
+	Column string
+	// Header 表头中的列名
+	Header string
+	Value  interface{}
+}
+
+// ProvenanceRecord 一个解码出的对象及其各字段来源的单元格
+type ProvenanceRecord struct {
+	MetaInfo interface{}
+	// Value 解码出的对象的指针
+	Value interface{}
+	// Fields 字段路径到单元格的映射 路径形如 Name Addr.City Items[0].Price Names[en]
+	Fields map[string]CellSource
+}
+
+// Provenance 字段来源收集器
+// 编码时作为opt传入, 每个编码成功的对象都会记录各字段来源的单元格
+type Provenance struct {
+	Records []ProvenanceRecord
+}
+
+// NewProvenance 实例化一个字段来源收集器
+func NewProvenance() *Provenance {
+	return &Provenance{}
+}
+
+// Add 记录一个解码出的对象 v必须为结构体指针
+func (p *Provenance) Add(metaInfo interface{}, v interface{}, fields map[string]CellSource) {
+	p.Records = append(p.Records, ProvenanceRecord{
+		MetaInfo: metaInfo,
+		Value:    v,
+		Fields:   fields,
+	})
+}
+
+// Get 获取对象各字段来源的单元格 v为解码出的对象的指针
+// 按指针查找, 适用于[]*T和单个结构体; []T在编码之后追加元素可能重新分配内存, 之前的元素地址失效, 此时使用GetByIndex
+func (p *Provenance) Get(v interface{}) (map[string]CellSource, bool) {
+	for _, record := range p.Records {
+		if record.Value == v {
+			return record.Fields, true
+		}
+	}
+	return nil, false
+}
+
+// GetByIndex 获取第index个解码出的对象各字段来源的单元格, 顺序与对象追加到结果中的顺序一致
+func (p *Provenance) GetByIndex(index int) (map[string]CellSource, bool) {
+	if index < 0 || index >= len(p.Records) {
+		return nil, false
+	}
+	return p.Records[index].Fields, true
+}
+
+// record 记录字段来源的单元格 path为当前行中的字段路径, column为当前行中的列名
+func (row *Row) record(path, column string, val interface{}) {
+	if row.sources == nil {
+		return
+	}
+	current := row
+	for current.parent != nil {
+		column = current.prefix + column
+		current = current.parent
+	}
+	sheetName, rowIndex := locate(current.MetaInfo)
+	source := CellSource{
+		Sheet:  sheetName,
+		Row:    rowIndex,
+		Header: column,
+		Value:  val,
+	}
+	if current.header != nil {
+		source.Column = current.header.columnLetter(column)
+	}
+	row.sources[row.path+path] = source
+}
+
+// itemPath 切片元素的字段路径
+func itemPath(path, name string, index int) string {
+	return path + name + "[" + strconv.Itoa(index) + "]."
+}
+
+// mapPath map元素的字段路径
+func mapPath(name, key string) string {
+	return name + "[" + key + "]"
+}
+
+func getProvenance(opt []interface{}) *Provenance {
+	for _, o := range opt {
+		if provenance, ok := o.(*Provenance); ok {
+			return provenance
+		}
+	}
+	return nil
+}
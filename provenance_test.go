@@ -0,0 +1,67 @@
+package dorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+type provenanceAddress struct {
+	City string `dorm:"name:市"`
+}
+
+type provenanceItem struct {
+	Name  string            `dorm:"name:名称"`
+	Addr  provenanceAddress `dorm:"name:地址"`
+	Extra map[string]string `dorm:"extra"`
+}
+
+func TestProvenance(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "客户", rows: [][]string{
+		{"名称", "地址-市", "备注"},
+		{"张三", "杭州", "老客户"},
+		{"李四", "宁波", ""},
+	}})
+	provenance := NewProvenance()
+	var items []provenanceItem
+	if err := mapper.Encode(&items, provenance); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]CellSource{
+		"Name":      {Sheet: "客户", Row: "1", Column: "A", Header: "名称", Value: "张三"},
+		"Addr.City": {Sheet: "客户", Row: "1", Column: "B", Header: "地址-市", Value: "杭州"},
+		"Extra[备注]": {Sheet: "客户", Row: "1", Column: "C", Header: "备注", Value: "老客户"},
+	}
+	fields, ok := provenance.Get(&items[0])
+	if !ok || !reflect.DeepEqual(fields, want) {
+		t.Errorf("got %v, want %v", fields, want)
+	}
+	items = append(items, provenanceItem{})
+	fields, ok = provenance.GetByIndex(1)
+	if !ok || fields["Name"].Value != "李四" || fields["Name"].Row != "2" {
+		t.Errorf("got %v for the second record", fields)
+	}
+	if _, ok := provenance.GetByIndex(2); ok {
+		t.Error("only two records were decoded")
+	}
+}
+
+func TestProvenanceNamedRangeColumn(t *testing.T) {
+	file := newWorkbook(testSheet{name: "数据", rows: [][]string{
+		{"说明"},
+		{"", "", "名称", "数量"},
+		{"", "", "苹果", "3"},
+	}})
+	mapper := openWorkbook(t, withDefinedNames(t, file, `<definedName name="商品">数据!$C$2:$D$3</definedName>`))
+	provenance := NewProvenance()
+	var items []*reportItem
+	if err := mapper.Encode(&items, NamedRange("商品"), provenance); err != nil {
+		t.Fatal(err)
+	}
+	fields, ok := provenance.Get(items[0])
+	if !ok {
+		t.Fatal("provenance not found")
+	}
+	if source := fields["Count"]; source.Column != "D" || source.Row != "2" {
+		t.Errorf("got %+v, want cell D3", source)
+	}
+}
@@ -0,0 +1,168 @@
+package dorm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	tablePathRE = regexp.MustCompile(`^xl/tables/[^/]+\.xml$`)
+	sheetRelsRE = regexp.MustCompile(`^xl/worksheets/_rels/sheet(\d+)\.xml\.rels$`)
+	axisRE      = regexp.MustCompile(`^([A-Za-z]{1,3})([0-9]+)$`)
+)
+
+// NamedRange 表格(ListObject)的名称或工作簿中定义的名称
+// 设置之后只读取该区域, 区域的第一行为表头, 可以通过ExcelParser.SetNamedRange设置, 也可以作为opt传入
+type NamedRange string
+
+// excelTable xl/tables/tableN.xml中的表格
+type excelTable struct {
+	Name           string `xml:"name,attr"`
+	DisplayName    string `xml:"displayName,attr"`
+	Ref            string `xml:"ref,attr"`
+	TotalsRowCount int    `xml:"totalsRowCount,attr"`
+}
+
+// excelRelationships sheet的关联文件
+type excelRelationships struct {
+	Relationships []struct {
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// SetNamedRange 设置读取的表格或定义的名称, opt中传入的NamedRange优先
+func (p *ExcelParser) SetNamedRange(name string) {
+	p.namedRange = NamedRange(name)
+}
+
+// rangeTable 读取表格或定义的名称对应的区域
+func (p *ExcelParser) rangeTable(name NamedRange, opt []interface{}) (sheetTable, error) {
+	sheetName, ref, totals, ok := p.resolveTable(string(name))
+	if !ok {
+		data, found := definedName(p.file, p.sheetName, string(name))
+		if !found {
+			return sheetTable{}, fmt.Errorf("table or defined name %q not found", name)
+		}
+		index := strings.LastIndex(data, "!")
+		if index < 0 {
+			return sheetTable{}, fmt.Errorf("defined name %q is not a range", name)
+		}
+		sheetName, ref = strings.Trim(data[:index], "'"), strings.Replace(data[index+1:], "$", "", -1)
+	}
+	startCol, startRow, endCol, endRow, ok := parseRange(ref)
+	if !ok {
+		return sheetTable{}, fmt.Errorf("invalid range %q of %q", ref, name)
+	}
+	endRow -= totals
+	sheetRows := p.file.GetRows(sheetName)
+	var rows [][]string
+	var lines []int
+	for r := startRow; r <= endRow; r++ {
+		row := make([]string, endCol-startCol+1)
+		if r-1 < len(sheetRows) {
+			for c := startCol; c <= endCol; c++ {
+				if c-1 < len(sheetRows[r-1]) {
+					row[c-startCol] = sheetRows[r-1][c-1]
+				}
+			}
+		}
+		rows = append(rows, row)
+		lines = append(lines, r-1)
+	}
+	layout, ok := getLayout(opt)
+	if !ok {
+		layout = p.layout
+	}
+	return layout.arrange(sheetTable{
+		sheetName: sheetName,
+		title:     string(name),
+		rows:      rows,
+		lines:     lines,
+		column:    startCol - 1,
+	}), nil
+}
+
+// resolveTable 在xl/tables中查找表格, 通过sheet的关联文件确定表格所在的sheet
+func (p *ExcelParser) resolveTable(name string) (sheetName, ref string, totals int, ok bool) {
+	var tablePath string
+	for filePath, content := range p.file.XLSX {
+		if !tablePathRE.MatchString(filePath) {
+			continue
+		}
+		var table excelTable
+		if err := xml.Unmarshal(content, &table); err != nil {
+			continue
+		}
+		if table.Name == name || table.DisplayName == name {
+			tablePath, ref, totals = filePath, table.Ref, table.TotalsRowCount
+			break
+		}
+	}
+	if tablePath == "" {
+		return "", "", 0, false
+	}
+	sheetMap := p.file.GetSheetMap()
+	for filePath, content := range p.file.XLSX {
+		match := sheetRelsRE.FindStringSubmatch(filePath)
+		if match == nil {
+			continue
+		}
+		var rels excelRelationships
+		if err := xml.Unmarshal(content, &rels); err != nil {
+			continue
+		}
+		for _, rel := range rels.Relationships {
+			if path.Join("xl/worksheets", rel.Target) == tablePath {
+				index, _ := strconv.Atoi(match[1])
+				return sheetMap[index], ref, totals, true
+			}
+		}
+	}
+	return "", "", 0, false
+}
+
+// parseRange 解析 A1:D10 形式的区域, 返回从1开始的列和行
+func parseRange(ref string) (startCol, startRow, endCol, endRow int, ok bool) {
+	parts := strings.Split(ref, ":")
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	if len(parts) != 2 {
+		return 0, 0, 0, 0, false
+	}
+	startCol, startRow, ok = parseAxis(parts[0])
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	endCol, endRow, ok = parseAxis(parts[1])
+	if !ok || endCol < startCol || endRow < startRow {
+		return 0, 0, 0, 0, false
+	}
+	return startCol, startRow, endCol, endRow, true
+}
+
+// parseAxis 解析 C3 形式的单元格地址
+func parseAxis(axis string) (col, row int, ok bool) {
+	match := axisRE.FindStringSubmatch(strings.TrimSpace(axis))
+	if match == nil {
+		return 0, 0, false
+	}
+	row, err := strconv.Atoi(match[2])
+	if err != nil || row <= 0 {
+		return 0, 0, false
+	}
+	return columnIndex(match[1]), row, true
+}
+
+func getNamedRange(opt []interface{}) (NamedRange, bool) {
+	for _, o := range opt {
+		if name, ok := o.(NamedRange); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
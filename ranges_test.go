@@ -0,0 +1,131 @@
+package dorm
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want [4]int
+		ok   bool
+	}{
+		{"A1:D10", [4]int{1, 1, 4, 10}, true},
+		{"c3:d5", [4]int{3, 3, 4, 5}, true},
+		{"B2", [4]int{2, 2, 2, 2}, true},
+		{"AA10:AB12", [4]int{27, 10, 28, 12}, true},
+		{"1:2", [4]int{}, false},
+		{"A1:B2:C3", [4]int{}, false},
+	}
+	for _, tt := range tests {
+		startCol, startRow, endCol, endRow, ok := parseRange(tt.ref)
+		if ok != tt.ok || (ok && [4]int{startCol, startRow, endCol, endRow} != tt.want) {
+			t.Errorf("%s: got %v %v %v %v %v", tt.ref, startCol, startRow, endCol, endRow, ok)
+		}
+	}
+}
+
+// stockTableSheet 在说明之后的top行left列放置库存表格
+func stockTableSheet(top, left int) testSheet {
+	rows := [][]string{{"库存说明"}}
+	for len(rows) < top-1 {
+		rows = append(rows, nil)
+	}
+	padding := make([]string, left-1)
+	for _, row := range [][]string{{"名称", "单价"}, {"苹果", "3"}, {"香蕉", "5"}} {
+		rows = append(rows, append(append([]string{}, padding...), row...))
+	}
+	rows = append(rows, []string{"合计", "8"})
+	return testSheet{name: "库存", rows: rows}
+}
+
+func TestEncodeExcelTable(t *testing.T) {
+	tests := []struct {
+		name      string
+		top, left int
+		lines     []int
+	}{
+		{name: "top left", top: 2, left: 1, lines: []int{3, 4}},
+		{name: "moved", top: 5, left: 3, lines: []int{6, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newWorkbook(
+				testSheet{name: "封面", rows: [][]string{{"库存"}}},
+				stockTableSheet(tt.top, tt.left),
+			)
+			start := ColumnLetter(tt.left) + strconv.Itoa(tt.top)
+			end := ColumnLetter(tt.left+1) + strconv.Itoa(tt.top+2)
+			if err := file.AddTable("库存", start, end, `{"table_name":"库存表","table_style":"TableStyleMedium2"}`); err != nil {
+				t.Fatal(err)
+			}
+			mapper := openWorkbook(t, file)
+			var items []tableItem
+			if err := mapper.Encode(&items, NamedRange("库存表")); err != nil {
+				t.Fatal(err)
+			}
+			want := []tableItem{{Name: "苹果", Price: 3, Row: tt.lines[0]}, {Name: "香蕉", Price: 5, Row: tt.lines[1]}}
+			if !reflect.DeepEqual(items, want) {
+				t.Errorf("got %+v, want %+v", items, want)
+			}
+		})
+	}
+}
+
+func TestEncodeDefinedNameRange(t *testing.T) {
+	file := newWorkbook(
+		testSheet{name: "封面", rows: [][]string{{"库存"}}},
+		testSheet{name: "库存 明细", rows: [][]string{
+			{"库存说明"},
+			{"", "名称", "单价"},
+			{"", "苹果", "3"},
+			{"", "合计", "3"},
+		}},
+	)
+	file = withDefinedNames(t, file, `<definedName name="库存">'库存 明细'!$B$2:$C$3</definedName>`)
+	tests := []struct {
+		name  string
+		setup func(mapper *DocumentMapper) []interface{}
+	}{
+		{name: "option", setup: func(mapper *DocumentMapper) []interface{} {
+			return []interface{}{NamedRange("库存")}
+		}},
+		{name: "parser setting", setup: func(mapper *DocumentMapper) []interface{} {
+			excelParser(mapper).SetNamedRange("库存")
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openWorkbook(t, file)
+			var items []tableItem
+			if err := mapper.Encode(&items, tt.setup(mapper)...); err != nil {
+				t.Fatal(err)
+			}
+			if want := []tableItem{{Name: "苹果", Price: 3, Row: 3}}; !reflect.DeepEqual(items, want) {
+				t.Errorf("got %+v, want %+v", items, want)
+			}
+		})
+	}
+}
+
+func TestEncodeNamedRangeErrors(t *testing.T) {
+	file := withDefinedNames(t, newWorkbook(stockTableSheet(2, 1)), `<definedName name="常量">100</definedName>`)
+	tests := []struct {
+		name NamedRange
+		want string
+	}{
+		{"不存在", "not found"},
+		{"常量", "is not a range"},
+	}
+	for _, tt := range tests {
+		mapper := openWorkbook(t, file)
+		var items []tableItem
+		if err := mapper.Encode(&items, tt.name); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package dorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	refTag = "REF"
+)
+
+// RefChecker 跨sheet引用完整性检查
+// 字段通过 ref:sheet名.列名 声明引用另一个sheet中的键字段, 例如 `dorm:"name:产品编码;ref:产品.编码"`
+// 列名可以是键字段任意语言的名称或别名, 没有设置name的字段使用编码时传入的NamingStrategy推导
+// 编码时作为opt传入, 各sheet都解码完成之后调用Check检查悬空引用
+type RefChecker struct {
+	records []refRecord
+}
+
+type refRecord struct {
+	metaInfo interface{}
+	value    interface{}
+	// opt 编码时的配置, 获取键字段的列名时使用相同的命名策略
+	opt []interface{}
+}
+
+// NewRefChecker 实例化一个引用检查器
+func NewRefChecker() *RefChecker {
+	return &RefChecker{}
+}
+
+// Add 记录一个解码出的对象 v必须为结构体指针
+func (c *RefChecker) Add(metaInfo interface{}, v interface{}) {
+	c.add(metaInfo, v, nil)
+}
+
+func (c *RefChecker) add(metaInfo interface{}, v interface{}, opt []interface{}) {
+	c.records = append(c.records, refRecord{
+		metaInfo: metaInfo,
+		value:    v,
+		opt:      opt,
+	})
+}
+
+// Check 检查所有记录的引用, 悬空引用以RowError返回
+func (c *RefChecker) Check() []error {
+	var errs []error
+	keySets := map[string]map[string]bool{}
+	for _, record := range c.records {
+		reflectValue := reflect.Indirect(reflect.ValueOf(record.value))
+		if reflectValue.Kind() != reflect.Struct {
+			continue
+		}
+		reflectType := reflectValue.Type()
+		for _, fieldStruct := range structFields(reflectType) {
+			tagSettings := parseTagSetting(fieldStruct.Tag)
+			ref, ok := tagSettings[refTag]
+			if !ok {
+				continue
+			}
+			fieldValue, ok := fieldByIndex(reflectValue, fieldStruct.Index, false)
+			if !ok {
+				continue
+			}
+			fieldValue = reflect.Indirect(fieldValue)
+			if !fieldValue.IsValid() || isZeroValue(fieldValue) {
+				continue
+			}
+			keySet, ok := keySets[ref]
+			if !ok {
+				sheetName, column := splitRef(ref)
+				keySet = c.keySet(sheetName, column)
+				keySets[ref] = keySet
+			}
+			val := fmt.Sprint(fieldValue.Interface())
+			if !keySet[val] {
+				errs = append(errs, WrapError(record.metaInfo, &FieldError{
+					Code:   CodeDanglingReference,
+					Column: primaryName(tagSettings["NAME"]),
+					Value:  val,
+					Detail: ref,
+				}))
+			}
+		}
+	}
+	return errs
+}
+
+// keySet 获取sheet中某一列的所有值
+func (c *RefChecker) keySet(sheetName, column string) map[string]bool {
+	keySet := map[string]bool{}
+	for _, record := range c.records {
+		if sheet, _ := locate(record.metaInfo); sheet != sheetName {
+			continue
+		}
+		if val, ok := keyValue(record, column); ok {
+			keySet[fmt.Sprint(val)] = true
+		}
+	}
+	return keySet
+}
+
+// keyValue 获取记录中列名为column的字段的值, 与字段所有语言的名称和别名比较
+func keyValue(record refRecord, column string) (interface{}, bool) {
+	reflectValue := reflect.Indirect(reflect.ValueOf(record.value))
+	if reflectValue.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for _, fieldStruct := range structFields(reflectValue.Type()) {
+		fieldValue, ok := fieldByIndex(reflectValue, fieldStruct.Index, false)
+		if !ok {
+			continue
+		}
+		field := newField(fieldStruct, fieldValue, record.opt)
+		for _, name := range tagNames(field.TagSettings) {
+			if containsString(splitAliases(name), column) {
+				val := indirectInterface(fieldValue)
+				return val, val != nil
+			}
+		}
+	}
+	return nil, false
+}
+
+// splitRef 将 sheet名.列名 拆分为sheet名和列名, 以最后一个.分隔, sheet名中可以包含.
+func splitRef(ref string) (string, string) {
+	index := strings.LastIndex(ref, ".")
+	if index < 0 {
+		return "", ref
+	}
+	return ref[:index], ref[index+1:]
+}
+
+func isZeroValue(value reflect.Value) bool {
+	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
+
+func getRefChecker(opt []interface{}) *RefChecker {
+	for _, o := range opt {
+		if checker, ok := o.(*RefChecker); ok {
+			return checker
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package dorm
+
+import (
+	"testing"
+)
+
+type refProduct struct {
+	Code string `dorm:"name:编码;name_en:Code"`
+	Name string `dorm:"name:名称;name_en:Name"`
+}
+
+type refOrder struct {
+	Product string `dorm:"name:产品;name_en:Product;ref:Product.v2.Code"`
+}
+
+type jsonProduct struct {
+	Code string `json:"code"`
+}
+
+type jsonOrder struct {
+	Product string `json:"product" dorm:"ref:产品.code"`
+}
+
+func TestSplitRef(t *testing.T) {
+	tests := []struct {
+		ref, sheet, column string
+	}{
+		{"产品.编码", "产品", "编码"},
+		{"Product.v2.Code", "Product.v2", "Code"},
+		{"编码", "", "编码"},
+	}
+	for _, tt := range tests {
+		if sheet, column := splitRef(tt.ref); sheet != tt.sheet || column != tt.column {
+			t.Errorf("%s: got (%s, %s), want (%s, %s)", tt.ref, sheet, column, tt.sheet, tt.column)
+		}
+	}
+}
+
+func TestRefCheckerWithEncodeOptions(t *testing.T) {
+	mapper := openSheets(t,
+		testSheet{name: "Product.v2", rows: [][]string{
+			{"Code", "Name"},
+			{"P1", "Apple"},
+		}},
+		testSheet{name: "Order", rows: [][]string{
+			{"Product"},
+			{"P1"},
+			{"P2"},
+		}},
+	)
+	checker := NewRefChecker()
+	var products []refProduct
+	var orders []refOrder
+	mapper.Bind("Product.v2", &products).Bind("Order", &orders)
+	if err := mapper.EncodeSheets(Locale("en"), checker); err != nil {
+		t.Fatal(err)
+	}
+	errs := checker.Check()
+	if len(errs) != 1 {
+		t.Fatalf("got %v, want one dangling reference", errs)
+	}
+	rowErr := errs[0].(*RowError)
+	if sheet, row := locate(rowErr.MetaInfo); sheet != "Order" || row != "2" {
+		t.Errorf("got %s %s, want Order 2", sheet, row)
+	}
+	if fieldErr := rowErr.Err.(*FieldError); fieldErr.Code != CodeDanglingReference || fieldErr.Value != "P2" {
+		t.Errorf("got %+v", fieldErr)
+	}
+}
+
+func TestRefCheckerWithNamingStrategy(t *testing.T) {
+	mapper := openSheets(t,
+		testSheet{name: "产品", rows: [][]string{
+			{"code"},
+			{"P1"},
+		}},
+		testSheet{name: "订单", rows: [][]string{
+			{"product"},
+			{"P1"},
+		}},
+	)
+	mapper.SetNamingStrategy(JSONNaming)
+	checker := NewRefChecker()
+	var products []jsonProduct
+	var orders []jsonOrder
+	mapper.Bind("产品", &products).Bind("订单", &orders)
+	if err := mapper.EncodeSheets(checker); err != nil {
+		t.Fatal(err)
+	}
+	if errs := checker.Check(); len(errs) != 0 {
+		t.Errorf("got %v, want no dangling reference", errs)
+	}
+}
+
+type aliasProduct struct {
+	Code string `dorm:"name:编码|产品编码;name_en:Code"`
+}
+
+type priceRow struct {
+	Product string `dorm:"name:产品;ref:产品.编码"`
+	Alias   string `dorm:"name:别名;ref:产品.产品编码"`
+	English string `dorm:"name:英文;ref:产品.Code"`
+}
+
+func TestRefCheckerIgnoresMessageLocale(t *testing.T) {
+	mapper := openSheets(t,
+		testSheet{name: "产品", rows: [][]string{
+			{"编码"},
+			{"P1"},
+		}},
+		testSheet{name: "价格", rows: [][]string{
+			{"产品", "别名", "英文"},
+			{"P1", "P1", "P1"},
+			{"P2", "", ""},
+		}},
+	)
+	mapper.SetLocale(LocaleEn)
+	checker := NewRefChecker()
+	var products []aliasProduct
+	var prices []priceRow
+	mapper.Bind("产品", &products).Bind("价格", &prices)
+	if err := mapper.EncodeSheets(checker); err != nil {
+		t.Fatal(err)
+	}
+	errs := checker.Check()
+	if len(errs) != 1 {
+		t.Fatalf("got %v, want only the dangling P2", errs)
+	}
+	if fieldErr := errs[0].(*RowError).Err.(*FieldError); fieldErr.Value != "P2" || fieldErr.Column != "产品" {
+		t.Errorf("got %+v", fieldErr)
+	}
+}
@@ -0,0 +1,123 @@
+package dorm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Report 导入报告 可直接序列化为JSON返回给前端
+type Report struct {
+	Summary  ReportSummary  `json:"summary"`
+	Errors   []ReportEntry  `json:"errors"`
+	Warnings []ReportEntry  `json:"warnings"`
+	Columns  []ColumnErrors `json:"columns"`
+}
+
+// ReportSummary 导入结果汇总
+type ReportSummary struct {
+	// TotalRows 读取到的行数
+	TotalRows int `json:"total_rows"`
+	// OkRows 编码成功的行数
+	OkRows int `json:"ok_rows"`
+	// FailedRows 出错的行数
+	FailedRows int `json:"failed_rows"`
+	// SkippedRows 由于错误策略提前停止而未处理, 或者AllOrNothing时被丢弃的行数
+	SkippedRows int `json:"skipped_rows"`
+}
+
+// ReportEntry 单个错误
+type ReportEntry struct {
+	Sheet   string      `json:"sheet"`
+	Row     string      `json:"row"`
+	Column  string      `json:"column"`
+	Value   interface{} `json:"value"`
+	Code    ErrorCode   `json:"code"`
+	Message string      `json:"message"`
+	// Suggestion 表头中相近的列
+	Suggestion string `json:"suggestion"`
+}
+
+// ColumnErrors 某一列的错误数
+type ColumnErrors struct {
+	Column string `json:"column"`
+	Count  int    `json:"count"`
+}
+
+// NewReport 根据总行数和GetErrors()的结果生成报告 没有出错的行都视为成功
+func NewReport(totalRows int, errs []error) *Report {
+	report := &Report{
+		Errors:   []ReportEntry{},
+		Warnings: []ReportEntry{},
+		Columns:  []ColumnErrors{},
+	}
+	failedRows := map[string]bool{}
+	columnCounts := map[string]int{}
+	for _, err := range errs {
+		var entries []ReportEntry
+		if headerErr, ok := err.(*HeaderError); ok {
+			for _, fieldErr := range headerErr.FieldErrors() {
+				entry := newReportEntry(fieldErr)
+				entry.Sheet = headerErr.SheetName
+				entries = append(entries, entry)
+			}
+		} else {
+			entries = append(entries, newReportEntry(err))
+		}
+		for _, entry := range entries {
+			report.Errors = append(report.Errors, entry)
+			if entry.Column != "" {
+				columnCounts[entry.Column]++
+			}
+		}
+		if rowErr, ok := err.(*RowError); ok {
+			failedRows[fmt.Sprint(rowErr.MetaInfo)] = true
+		}
+	}
+	for column, count := range columnCounts {
+		report.Columns = append(report.Columns, ColumnErrors{
+			Column: column,
+			Count:  count,
+		})
+	}
+	sort.Slice(report.Columns, func(i, j int) bool {
+		return report.Columns[i].Column < report.Columns[j].Column
+	})
+	report.Summary = ReportSummary{
+		TotalRows:  totalRows,
+		OkRows:     totalRows - len(failedRows),
+		FailedRows: len(failedRows),
+	}
+	return report
+}
+
+func newReportEntry(err error) ReportEntry {
+	var entry ReportEntry
+	if rowErr, ok := err.(*RowError); ok {
+		entry.Sheet, entry.Row = locate(rowErr.MetaInfo)
+		entry.Message = rowErr.ErrorInfo
+		if rowErr.Err != nil {
+			err = rowErr.Err
+		}
+	} else {
+		entry.Message = err.Error()
+	}
+	if fieldErr, ok := err.(*FieldError); ok {
+		entry.Column = fieldErr.Column
+		entry.Value = fieldErr.Value
+		entry.Code = fieldErr.Code
+		entry.Message = fieldErr.Error()
+		entry.Suggestion = fieldErr.Suggestion
+	}
+	return entry
+}
+
+// Report 生成最近一次Encode的导入报告
+func (mapper *DocumentMapper) Report() *Report {
+	report := NewReport(mapper.summary.total, mapper.errs)
+	report.Summary.OkRows = mapper.summary.ok
+	report.Summary.SkippedRows = mapper.summary.total - mapper.summary.ok - report.Summary.FailedRows
+	for _, warning := range mapper.summary.warnings {
+		report.Warnings = append(report.Warnings, newReportEntry(warning))
+	}
+	return report
+}
@@ -0,0 +1,59 @@
+package dorm
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type reportItem struct {
+	Name  string `dorm:"name:名称"`
+	Count int    `dorm:"name:数量"`
+}
+
+func TestReportMatchesResult(t *testing.T) {
+	rows := [][]string{
+		{"名称", "数量"},
+		{"a", "1"},
+		{"b", "x"},
+		{"c", "3"},
+		{"d", "y"},
+	}
+	tests := []struct {
+		name    string
+		policy  ErrorPolicy
+		summary ReportSummary
+	}{
+		{name: "collect all", policy: CollectAll, summary: ReportSummary{TotalRows: 4, OkRows: 2, FailedRows: 2}},
+		{name: "fail fast", policy: FailFast, summary: ReportSummary{TotalRows: 4, OkRows: 1, FailedRows: 1, SkippedRows: 2}},
+		{name: "max errors", policy: MaxErrors(2), summary: ReportSummary{TotalRows: 4, OkRows: 2, FailedRows: 2}},
+		{name: "all or nothing", policy: AllOrNothing, summary: ReportSummary{TotalRows: 4, OkRows: 0, FailedRows: 2, SkippedRows: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, testSheet{name: "库存", rows: rows})
+			mapper.SetErrorPolicy(tt.policy)
+			var items []*reportItem
+			if err := mapper.Encode(&items); err == nil {
+				t.Fatal("expected row errors")
+			}
+			report := mapper.Report()
+			if report.Summary != tt.summary {
+				t.Errorf("got summary %+v, want %+v", report.Summary, tt.summary)
+			}
+			if report.Summary.OkRows != len(items) {
+				t.Errorf("report has %d ok rows, result has %d items", report.Summary.OkRows, len(items))
+			}
+			if len(report.Errors) != tt.summary.FailedRows {
+				t.Errorf("got %d errors, want %d", len(report.Errors), tt.summary.FailedRows)
+			}
+			for _, entry := range report.Errors {
+				if entry.Sheet != "库存" || entry.Column != "数量" || entry.Code == "" {
+					t.Errorf("unexpected entry %+v", entry)
+				}
+			}
+			if _, err := json.Marshal(report); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package dorm
+
+import (
+	"path/filepath"
+	"regexp"
+)
+
+// SheetInfo 选择sheet时可用的信息
+type SheetInfo struct {
+	// Index sheet的序号, 从1开始
+	Index  int
+	Name   string
+	Hidden bool
+}
+
+// SheetSelector 选择需要读取的sheet 多个选择器同时生效, 所有选择器都返回true的sheet才会被读取
+// 可以通过ExcelParser.SetSheetSelector设置, 也可以作为opt传入
+type SheetSelector func(sheet SheetInfo) bool
+
+// SheetIndex 按序号选择sheet, 序号从1开始
+func SheetIndex(indexes ...int) SheetSelector {
+	return func(sheet SheetInfo) bool {
+		for _, index := range indexes {
+			if sheet.Index == index {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SheetGlob 按通配符选择sheet 例如 2024-*
+func SheetGlob(pattern string) SheetSelector {
+	return func(sheet SheetInfo) bool {
+		ok, err := filepath.Match(pattern, sheet.Name)
+		return err == nil && ok
+	}
+}
+
+// SheetRegexp 按正则表达式选择sheet
+func SheetRegexp(reg *regexp.Regexp) SheetSelector {
+	return func(sheet SheetInfo) bool {
+		return reg.MatchString(sheet.Name)
+	}
+}
+
+// IncludeSheets 只读取指定名称的sheet
+func IncludeSheets(names ...string) SheetSelector {
+	return func(sheet SheetInfo) bool {
+		return containsString(names, sheet.Name)
+	}
+}
+
+// ExcludeSheets 不读取指定名称的sheet 例如 说明
+func ExcludeSheets(names ...string) SheetSelector {
+	return func(sheet SheetInfo) bool {
+		return !containsString(names, sheet.Name)
+	}
+}
+
+// SkipHiddenSheets 不读取隐藏的sheet
+func SkipHiddenSheets(sheet SheetInfo) bool {
+	return !sheet.Hidden
+}
+
+// AnySheet 任意一个选择器返回true即选择该sheet
+func AnySheet(selectors ...SheetSelector) SheetSelector {
+	return func(sheet SheetInfo) bool {
+		for _, selector := range selectors {
+			if selector(sheet) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// selectSheet 所有选择器都返回true时选择该sheet
+func selectSheet(sheet SheetInfo, selectors []SheetSelector) bool {
+	for _, selector := range selectors {
+		if !selector(sheet) {
+			return false
+		}
+	}
+	return true
+}
+
+func getSheetSelectors(opt []interface{}) []SheetSelector {
+	var selectors []SheetSelector
+	for _, o := range opt {
+		switch selector := o.(type) {
+		case SheetSelector:
+			selectors = append(selectors, selector)
+		case func(SheetInfo) bool:
+			selectors = append(selectors, selector)
+		}
+	}
+	return selectors
+}
@@ -0,0 +1,88 @@
+package dorm
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSheetSelectors(t *testing.T) {
+	sheets := []SheetInfo{
+		{Index: 1, Name: "说明"},
+		{Index: 2, Name: "2024-01"},
+		{Index: 3, Name: "2024-02", Hidden: true},
+		{Index: 4, Name: "2023-12"},
+	}
+	tests := []struct {
+		name     string
+		selector SheetSelector
+		want     []string
+	}{
+		{"index", SheetIndex(2, 4), []string{"2024-01", "2023-12"}},
+		{"glob", SheetGlob("2024-*"), []string{"2024-01", "2024-02"}},
+		{"invalid glob", SheetGlob("[2024"), nil},
+		{"regexp", SheetRegexp(regexp.MustCompile(`^\d{4}-12$`)), []string{"2023-12"}},
+		{"include", IncludeSheets("说明", "不存在"), []string{"说明"}},
+		{"exclude", ExcludeSheets("说明"), []string{"2024-01", "2024-02", "2023-12"}},
+		{"skip hidden", SkipHiddenSheets, []string{"说明", "2024-01", "2023-12"}},
+		{"any", AnySheet(SheetIndex(1), SheetGlob("2023-*")), []string{"说明", "2023-12"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, sheet := range sheets {
+				if tt.selector(sheet) {
+					got = append(got, sheet.Name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func monthSheets(t *testing.T) *DocumentMapper {
+	header := []string{"名称", "数量"}
+	file := newWorkbook(
+		testSheet{name: "说明", rows: [][]string{{"请按月份填写"}}},
+		testSheet{name: "2024-01", rows: [][]string{header, {"苹果", "1"}}},
+		testSheet{name: "2024-02", rows: [][]string{header, {"香蕉", "2"}}},
+		testSheet{name: "2023-12", rows: [][]string{header, {"梨", "3"}}},
+	)
+	// excelize按前一个sheet是否选中判断能否隐藏, 保持第一个sheet为活动sheet
+	file.SetActiveSheet(1)
+	file.SetSheetVisible("2024-02", false)
+	return openWorkbook(t, file)
+}
+
+func TestEncodeSelectedSheets(t *testing.T) {
+	tests := []struct {
+		name      string
+		selectors []SheetSelector
+		opt       []interface{}
+		want      []string
+	}{
+		{name: "mapper selectors", selectors: []SheetSelector{ExcludeSheets("说明"), SkipHiddenSheets}, want: []string{"2024-01", "2023-12"}},
+		{name: "option", opt: []interface{}{SheetGlob("2024-*")}, want: []string{"2024-01", "2024-02"}},
+		{name: "mapper and option", selectors: []SheetSelector{SkipHiddenSheets}, opt: []interface{}{SheetGlob("2024-*")}, want: []string{"2024-01"}},
+		{name: "plain function", opt: []interface{}{func(sheet SheetInfo) bool { return sheet.Index == 4 }}, want: []string{"2023-12"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := monthSheets(t)
+			mapper.SetSheetSelector(tt.selectors...)
+			var items []metaItem
+			if err := mapper.Encode(&items, tt.opt...); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, item := range items {
+				got = append(got, item.Sheet)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got sheets %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
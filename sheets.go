@@ -0,0 +1,160 @@
+package dorm
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// sheetBinding sheet名或通配符对应的目标切片
+type sheetBinding struct {
+	pattern string
+	target  interface{}
+}
+
+// match sheet名是否与绑定匹配, pattern支持 * ? 等通配符
+func (b sheetBinding) match(sheetName string) bool {
+	if b.pattern == sheetName {
+		return true
+	}
+	ok, err := filepath.Match(b.pattern, sheetName)
+	return err == nil && ok
+}
+
+// Bind 将sheet绑定到目标切片, pattern为sheet名或通配符 一个sheet匹配多个绑定时使用第一个
+// 绑定之后调用EncodeSheets一次读取整个文档, 每个sheet解码为各自的类型
+func (mapper *DocumentMapper) Bind(pattern string, v interface{}) *DocumentMapper {
+	mapper.bindings = append(mapper.bindings, sheetBinding{pattern: pattern, target: v})
+	return mapper
+}
+
+// EncodeSheets 读取一次文档, 将每个sheet编码到Bind绑定的切片中, 没有绑定的sheet被忽略
+// 错误策略对每个sheet单独生效, 每个sheet的错误可以通过GetSheetErrors获取
+func (mapper *DocumentMapper) EncodeSheets(opt ...interface{}) error {
+	if len(mapper.bindings) == 0 {
+		return errors.New("no sheet bound")
+	}
+	options := mapper.options(opt)
+	sheets, err := readSheets(mapper.parser, options)
+	if err != nil {
+		return err
+	}
+	mapper.errs = nil
+	mapper.summary = encodeSummary{}
+	mapper.sheetErrs = map[string][]error{}
+	for _, sheet := range sheets {
+		binding, ok := mapper.sheetBinding(sheet.name)
+		if !ok {
+			continue
+		}
+		errs, summary, err := encodeByParser(sheet, binding.target, options...)
+		if err != nil {
+			switch e := err.(type) {
+			case *HeaderError:
+				errs = []error{e}
+			case Errors:
+				errs = e
+			default:
+				return err
+			}
+		}
+		mapper.summary.total += summary.total
+		mapper.summary.ok += summary.ok
+		mapper.summary.warnings = append(mapper.summary.warnings, summary.warnings...)
+		if len(errs) > 0 {
+			mapper.sheetErrs[sheet.name] = errs
+			mapper.errs = append(mapper.errs, errs...)
+		}
+	}
+	if len(mapper.errs) > 0 {
+		return Errors(mapper.errs)
+	}
+	return nil
+}
+
+// GetSheetErrors 获取EncodeSheets中指定sheet的错误
+func (mapper *DocumentMapper) GetSheetErrors(sheetName string) []error {
+	return mapper.sheetErrs[sheetName]
+}
+
+func (mapper *DocumentMapper) sheetBinding(sheetName string) (sheetBinding, bool) {
+	for _, binding := range mapper.bindings {
+		if binding.match(sheetName) {
+			return binding, true
+		}
+	}
+	return sheetBinding{}, false
+}
+
+// sheetRows 一个sheet已经读取的行和表头, 用于按sheet分别编码
+type sheetRows struct {
+	name    string
+	rows    []RowInterface
+	headers []*Header
+}
+
+// ReadToRows 读取并解析道行数据列表
+func (s *sheetRows) ReadToRows(opt ...interface{}) ([]RowInterface, error) {
+	return s.rows, nil
+}
+
+// ReadHeaders 读取每个sheet的表头
+func (s *sheetRows) ReadHeaders(opt ...interface{}) ([]*Header, error) {
+	return s.headers, nil
+}
+
+// documentReader 能一次读取表头和行数据的解析器
+type documentReader interface {
+	readDocument(opt []interface{}) ([]*Header, []RowInterface, error)
+}
+
+// readDocument 读取表头和行数据 解析器不能一次读取时分别调用ReadHeaders和ReadToRows
+func readDocument(parser Parser, opt []interface{}) ([]*Header, []RowInterface, error) {
+	if reader, ok := parser.(documentReader); ok {
+		return reader.readDocument(opt)
+	}
+	var headers []*Header
+	if headerParser, ok := parser.(HeaderParser); ok {
+		var err error
+		headers, err = headerParser.ReadHeaders(opt...)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	rows, err := parser.ReadToRows(opt...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return headers, rows, nil
+}
+
+// readSheets 读取所有行并按sheet分组, sheet的顺序与文档中一致
+func readSheets(parser Parser, opt []interface{}) ([]*sheetRows, error) {
+	if parser == nil {
+		return nil, errors.New("parser not set")
+	}
+	var sheets []*sheetRows
+	index := map[string]*sheetRows{}
+	sheet := func(name string) *sheetRows {
+		if s, ok := index[name]; ok {
+			return s
+		}
+		s := &sheetRows{name: name}
+		index[name] = s
+		sheets = append(sheets, s)
+		return s
+	}
+	headers, rows, err := readDocument(parser, opt)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range headers {
+		s := sheet(header.SheetName)
+		s.headers = append(s.headers, header)
+	}
+	for _, row := range rows {
+		name, _ := locate(row.GetMetaInfo())
+		s := sheet(name)
+		s.rows = append(s.rows, row)
+	}
+	return sheets, nil
+}
@@ -0,0 +1,143 @@
+package dorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+type customerRow struct {
+	Name string `dorm:"name:客户;required"`
+	City string `dorm:"name:城市"`
+}
+
+func stockSheets() []testSheet {
+	return []testSheet{
+		{name: "商品", rows: [][]string{
+			{"名称", "单价"},
+			{"苹果", "3"},
+			{"香蕉", "x"},
+		}},
+		{name: "客户", rows: [][]string{
+			{"客户", "城市"},
+			{"张三", "杭州"},
+		}},
+		{name: "说明", rows: [][]string{
+			{"不需要读取"},
+		}},
+	}
+}
+
+func TestEncodeSheets(t *testing.T) {
+	mapper := openSheets(t, stockSheets()...)
+	var items []tableItem
+	var customers []*customerRow
+	err := mapper.Bind("商品", &items).Bind("客*", &customers).EncodeSheets()
+	if errs, ok := err.(Errors); !ok || len(errs) != 1 {
+		t.Fatalf("got %v, want one row error", err)
+	}
+	if want := []tableItem{{Name: "苹果", Price: 3, Row: 2}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("got %v, want %v", items, want)
+	}
+	if want := []*customerRow{{Name: "张三", City: "杭州"}}; !reflect.DeepEqual(customers, want) {
+		t.Errorf("got %v, want %v", customers, want)
+	}
+	if errs := mapper.GetSheetErrors("商品"); len(errs) != 1 {
+		t.Errorf("got sheet errors %v, want one", errs)
+	}
+	if errs := mapper.GetSheetErrors("客户"); len(errs) != 0 {
+		t.Errorf("got sheet errors %v, want none", errs)
+	}
+	if summary := mapper.Report().Summary; summary.TotalRows != 3 || summary.OkRows != 2 || summary.FailedRows != 1 {
+		t.Errorf("got summary %+v", summary)
+	}
+}
+
+func TestEncodeSheetsHeaderError(t *testing.T) {
+	sheets := stockSheets()
+	sheets[1].rows[0][0] = "姓名"
+	mapper := openSheets(t, sheets...)
+	var items []tableItem
+	var customers []customerRow
+	if err := mapper.Bind("商品", &items).Bind("客户", &customers).EncodeSheets(); err == nil {
+		t.Fatal("expected a header error")
+	}
+	errs := mapper.GetSheetErrors("客户")
+	if len(errs) != 1 {
+		t.Fatalf("got %v, want one header error", errs)
+	}
+	if headerErr, ok := errs[0].(*HeaderError); !ok || !reflect.DeepEqual(headerErr.Missing, []string{"客户"}) {
+		t.Errorf("got %v, want missing 客户", errs[0])
+	}
+	if len(items) != 1 || len(customers) != 0 {
+		t.Errorf("got items %v customers %v", items, customers)
+	}
+}
+
+// countingParser 记录读取文档的次数
+type countingParser struct {
+	*ExcelParser
+	headers, rows, documents int
+}
+
+func (p *countingParser) ReadHeaders(opt ...interface{}) ([]*Header, error) {
+	p.headers++
+	return p.ExcelParser.ReadHeaders(opt...)
+}
+
+func (p *countingParser) ReadToRows(opt ...interface{}) ([]RowInterface, error) {
+	p.rows++
+	return p.ExcelParser.ReadToRows(opt...)
+}
+
+func (p *countingParser) readDocument(opt []interface{}) ([]*Header, []RowInterface, error) {
+	p.documents++
+	return p.ExcelParser.readDocument(opt)
+}
+
+// plainParser 只能分别读取表头和行数据的解析器
+type plainParser struct {
+	parser *countingParser
+}
+
+func (p *plainParser) ReadHeaders(opt ...interface{}) ([]*Header, error) {
+	return p.parser.ReadHeaders(opt...)
+}
+
+func (p *plainParser) ReadToRows(opt ...interface{}) ([]RowInterface, error) {
+	return p.parser.ReadToRows(opt...)
+}
+
+func TestEncodeSheetsReadsOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		plain bool
+		want  [3]int
+	}{
+		{name: "excel parser", want: [3]int{0, 0, 1}},
+		{name: "custom parser", plain: true, want: [3]int{1, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, stockSheets()...)
+			counting := &countingParser{ExcelParser: excelParser(mapper)}
+			if tt.plain {
+				mapper.SetParser(&plainParser{parser: counting})
+			} else {
+				mapper.SetParser(counting)
+			}
+			mapper.SetHeaderPolicy(HeaderPolicy{Strict: true})
+			var items []tableItem
+			var customers []customerRow
+			mapper.Bind("商品", &items).Bind("客户", &customers)
+			if err := mapper.EncodeSheets(); err == nil {
+				t.Fatal("expected the row error of 香蕉")
+			}
+			if got := [3]int{counting.headers, counting.rows, counting.documents}; got != tt.want {
+				t.Errorf("got reads %v, want %v", got, tt.want)
+			}
+			if len(items) != 1 || len(customers) != 1 {
+				t.Errorf("got items %v customers %v", items, customers)
+			}
+		})
+	}
+}
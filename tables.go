@@ -0,0 +1,100 @@
+package dorm
+
+import (
+	"strings"
+)
+
+// TableSplit 将一个sheet拆分为多个表格, 每个表格的第一行为表头
+// 可以通过ExcelParser.SetTableSplit设置, 也可以作为opt传入
+type TableSplit struct {
+	// Marker 第一个非空单元格以Marker开头的行为表格的标题行, 下一行为表头, 表格中的空行被忽略
+	// Marker为空时以空行分隔表格
+	Marker string
+	// TitleRow 以空行分隔时, 每个表格的第一行为标题行, 第二行为表头
+	TitleRow bool
+}
+
+// sheetTable sheet中的一个表格 rows的第一行为表头
+type sheetTable struct {
+	sheetName string
+	// index 表格的序号, 从1开始, 没有拆分时为0
+	index int
+	title string
+	rows  [][]string
+	// lines rows中每一行在sheet中的序号, 从0开始, 纵向布局转换之后为列的序号
+	lines []int
+	// column 表格的第一列在sheet中的序号, 从0开始
+	column int
+	// form 表单布局, 整个表格为一条没有表头的记录, 字段通过cell标签读取
+	form bool
+}
+
+// split 拆分sheet中的表格
+func (split TableSplit) split(sheetName string, rows [][]string) []sheetTable {
+	var tables []sheetTable
+	current := -1
+	for i, row := range rows {
+		first, blank := firstCell(row)
+		if split.Marker != "" {
+			if !blank && strings.HasPrefix(first, split.Marker) {
+				tables = append(tables, sheetTable{
+					sheetName: sheetName,
+					index:     len(tables) + 1,
+					title:     first,
+				})
+				current = len(tables) - 1
+				continue
+			}
+			if blank || current < 0 {
+				continue
+			}
+		} else {
+			if blank {
+				current = -1
+				continue
+			}
+			if current < 0 {
+				tables = append(tables, sheetTable{
+					sheetName: sheetName,
+					index:     len(tables) + 1,
+				})
+				current = len(tables) - 1
+				if split.TitleRow {
+					tables[current].title = first
+					continue
+				}
+			}
+		}
+		tables[current].rows = append(tables[current].rows, row)
+		tables[current].lines = append(tables[current].lines, i)
+	}
+	return tables
+}
+
+// wholeSheet 没有拆分时整个sheet为一个表格
+func wholeSheet(sheetName string, rows [][]string) sheetTable {
+	lines := make([]int, len(rows))
+	for i := range lines {
+		lines[i] = i
+	}
+	return sheetTable{sheetName: sheetName, rows: rows, lines: lines}
+}
+
+// firstCell 获取行中第一个非空的单元格, 整行为空时blank为true
+func firstCell(row []string) (string, bool) {
+	for _, cell := range row {
+		if cell = strings.TrimSpace(cell); cell != "" {
+			return cell, false
+		}
+	}
+	return "", true
+}
+
+func getTableSplit(opt []interface{}) (TableSplit, bool) {
+	for _, o := range opt {
+		if split, ok := o.(TableSplit); ok {
+			return split, true
+		}
+	}
+	return TableSplit{}, false
+}
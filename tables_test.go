@@ -0,0 +1,147 @@
+package dorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+type tableItem struct {
+	Name  string `dorm:"name:名称"`
+	Price int    `dorm:"name:单价"`
+	Row   int    `dorm:"meta:row"`
+}
+
+func TestTableSplit(t *testing.T) {
+	rows := [][]string{
+		{"# 水果"},
+		{},
+		{"名称", "单价"},
+		{"苹果", "3"},
+		{},
+		{"香蕉", "2"},
+		{"# 蔬菜"},
+		{"单价", "名称", "产地"},
+		{},
+		{"4", "白菜", "山东"},
+	}
+	tests := []struct {
+		name   string
+		split  TableSplit
+		sheet  [][]string
+		items  []tableItem
+		tables []int
+	}{
+		{
+			name:   "marker",
+			split:  TableSplit{Marker: "#"},
+			sheet:  rows,
+			items:  []tableItem{{"苹果", 3, 4}, {"香蕉", 2, 6}, {"白菜", 4, 10}},
+			tables: []int{1, 1, 2},
+		},
+		{
+			name:  "blank rows",
+			split: TableSplit{},
+			sheet: [][]string{
+				{"名称", "单价"},
+				{"苹果", "3"},
+				{},
+				{},
+				{"单价", "名称"},
+				{"2", "香蕉"},
+			},
+			items:  []tableItem{{"苹果", 3, 2}, {"香蕉", 2, 6}},
+			tables: []int{1, 2},
+		},
+		{
+			name:  "title row",
+			split: TableSplit{TitleRow: true},
+			sheet: [][]string{
+				{"水果"},
+				{"名称", "单价"},
+				{"苹果", "3"},
+				{},
+				{"蔬菜"},
+				{"单价", "名称"},
+				{"4", "白菜"},
+			},
+			items:  []tableItem{{"苹果", 3, 3}, {"白菜", 4, 7}},
+			tables: []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapper := openSheets(t, testSheet{name: "商品", rows: tt.sheet})
+			var items []tableItem
+			if err := mapper.Encode(&items, tt.split); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(items, tt.items) {
+				t.Fatalf("got %v, want %v", items, tt.items)
+			}
+			rows, err := mapper.parser.ReadToRows(tt.split)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, row := range rows {
+				if index := row.GetMetaInfo().(MetaInfo).TableIndex; index != tt.tables[i] {
+					t.Errorf("row %d table index %d, want %d", i, index, tt.tables[i])
+				}
+			}
+		})
+	}
+}
+
+type stockItem struct {
+	Name     string `dorm:"name:Name"`
+	Quantity int    `dorm:"name:Quantity"`
+	Row      int    `dorm:"meta:row"`
+}
+
+func TestTableSplitAutoBind(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "Stock", rows: [][]string{
+		{"# Fruit"},
+		{"Name", "Quantity"},
+		{"Apple", "3"},
+		{"# Vegetable"},
+		{"Quantty", "Name"},
+		{},
+		{"4", "Cabbage"},
+	}})
+	mapper.SetHeaderPolicy(HeaderPolicy{AutoBind: true})
+	var items []stockItem
+	if err := mapper.Encode(&items, TableSplit{Marker: "#"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []stockItem{{"Apple", 3, 3}, {"Cabbage", 4, 7}}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("got %v, want %v", items, want)
+	}
+	if warnings := mapper.GetWarnings(); len(warnings) != 1 {
+		t.Fatalf("got warnings %v, want one auto-bind warning for the second table", warnings)
+	}
+}
+
+func TestTableSplitRowErrors(t *testing.T) {
+	mapper := openSheets(t, testSheet{name: "商品", rows: [][]string{
+		{"# 水果"},
+		{},
+		{"名称", "单价"},
+		{"苹果", "abc"},
+	}})
+	var items []tableItem
+	err := mapper.Encode(&items, TableSplit{Marker: "#"})
+	if err == nil {
+		t.Fatal("expected an error for the invalid price")
+	}
+	errs := mapper.GetErrors()
+	if len(errs) != 1 {
+		t.Fatalf("got %v, want one error", errs)
+	}
+	rowErr, ok := errs[0].(*RowError)
+	if !ok {
+		t.Fatalf("got %T, want *RowError", errs[0])
+	}
+	if sheet, row := locate(rowErr.MetaInfo); sheet != "商品" || row != "3" {
+		t.Errorf("error located at %s %s, want 商品 3", sheet, row)
+	}
+}
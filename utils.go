@@ -8,6 +8,9 @@ import (
 // ConvertToType 将reflect.Value的值 暂支持目前这几种
 func ConvertToType(value reflect.Value, v interface{}) interface{} {
 	kind := value.Kind()
+	if kind == reflect.Ptr {
+		kind = value.Type().Elem().Kind()
+	}
 	s, ok := v.(string)
 	if !ok {
 		return v
@@ -37,7 +40,51 @@ func ConvertToType(value reflect.Value, v interface{}) interface{} {
 			return v
 		}
 		return ret
+	case reflect.Int8:
+		ret, err := strconv.ParseInt(s, 10, 8)
+		if err != nil {
+			return v
+		}
+		return int8(ret)
+	case reflect.Int16:
+		ret, err := strconv.ParseInt(s, 10, 16)
+		if err != nil {
+			return v
+		}
+		return int16(ret)
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		ret, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return v
+		}
+		return ret
+	case reflect.Float32, reflect.Float64:
+		ret, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return v
+		}
+		return ret
+	case reflect.Bool:
+		ret, err := strconv.ParseBool(s)
+		if err != nil {
+			return v
+		}
+		return ret
 	default:
 		return v
 	}
 }
+
+// convertValue 将单元格的值转换为指定类型
+func convertValue(typ reflect.Type, v interface{}) (reflect.Value, error) {
+	elem := reflect.New(typ).Elem()
+	field := &Field{
+		StructField: &StructField{
+			Name:   typ.String(),
+			Struct: reflect.StructField{Type: typ},
+		},
+		Field: elem,
+	}
+	err := field.Set(ConvertToType(elem, v))
+	return elem, err
+}